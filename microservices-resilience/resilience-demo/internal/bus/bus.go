@@ -0,0 +1,136 @@
+// Package bus provides a lightweight publish/subscribe message bus used to turn
+// request/response flows (like inventory reservation) into asynchronous sagas.
+package bus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Message is an event published on the bus.
+type Message struct {
+	ID        string
+	Topic     string
+	Payload   []byte
+	Timestamp time.Time
+}
+
+// HandlerFunc processes a single message. Handlers must be idempotent on whatever key the
+// message carries (e.g. order_id + item_id): a crash between receive and ack can cause a
+// durable bus to redeliver a message that was already applied.
+type HandlerFunc func(msg Message) error
+
+// MessageBus publishes and delivers messages by topic.
+type MessageBus interface {
+	Publish(topic string, msg Message) error
+	Subscribe(topic string, handler HandlerFunc) error
+	// Stats reports, per topic, the head offset and each subscriber's consumer offset so
+	// Prometheus can alert on lag (head - consumer offset) for a stalled consumer.
+	Stats() []TopicStats
+}
+
+// TopicStats reports delivery lag for a single topic.
+type TopicStats struct {
+	Topic           string            `json:"topic"`
+	HeadOffset      uint64            `json:"head_offset"`
+	ConsumerOffsets map[string]uint64 `json:"consumer_offsets"`
+}
+
+type subscription struct {
+	id      string
+	handler HandlerFunc
+	offset  uint64
+}
+
+type topicState struct {
+	mutex         sync.Mutex
+	head          uint64
+	subscriptions []*subscription
+	queue         chan Message
+}
+
+// InMemoryBus is a channel-based MessageBus with no persistence: messages published before a
+// process crash or restart are lost. Suited to tests and to the in-process fan-out that
+// WALBus builds on.
+type InMemoryBus struct {
+	mutex  sync.Mutex
+	topics map[string]*topicState
+}
+
+// NewInMemoryBus creates an empty in-memory bus.
+func NewInMemoryBus() *InMemoryBus {
+	return &InMemoryBus{topics: make(map[string]*topicState)}
+}
+
+func (b *InMemoryBus) topicFor(name string) *topicState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topicState{queue: make(chan Message, 256)}
+		b.topics[name] = t
+		go b.dispatch(t)
+	}
+	return t
+}
+
+func (b *InMemoryBus) dispatch(t *topicState) {
+	for msg := range t.queue {
+		t.mutex.Lock()
+		subs := append([]*subscription(nil), t.subscriptions...)
+		t.mutex.Unlock()
+
+		for _, sub := range subs {
+			if err := sub.handler(msg); err != nil {
+				// The handler owns its own retry/dead-letter policy; the bus just moves on.
+				continue
+			}
+			t.mutex.Lock()
+			sub.offset++
+			t.mutex.Unlock()
+		}
+	}
+}
+
+// Publish delivers msg to every current subscriber of topic.
+func (b *InMemoryBus) Publish(topic string, msg Message) error {
+	t := b.topicFor(topic)
+	t.mutex.Lock()
+	t.head++
+	t.mutex.Unlock()
+	t.queue <- msg
+	return nil
+}
+
+// Subscribe registers handler to receive every message published to topic from now on.
+func (b *InMemoryBus) Subscribe(topic string, handler HandlerFunc) error {
+	t := b.topicFor(topic)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.subscriptions = append(t.subscriptions, &subscription{
+		id:      fmt.Sprintf("%s-%d", topic, len(t.subscriptions)),
+		handler: handler,
+	})
+	return nil
+}
+
+// Stats reports head/consumer offsets for every topic that has been published to.
+func (b *InMemoryBus) Stats() []TopicStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := make([]TopicStats, 0, len(b.topics))
+	for name, t := range b.topics {
+		t.mutex.Lock()
+		offsets := make(map[string]uint64, len(t.subscriptions))
+		for _, sub := range t.subscriptions {
+			offsets[sub.id] = sub.offset
+		}
+		stats = append(stats, TopicStats{Topic: name, HeadOffset: t.head, ConsumerOffsets: offsets})
+		t.mutex.Unlock()
+	}
+	return stats
+}
@@ -0,0 +1,181 @@
+package bus
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type walRecord struct {
+	Message Message
+}
+
+// WALBus is a durable MessageBus: every Publish is appended to a write-ahead log and fsynced
+// before fan-out, and a processed-set of message IDs is persisted alongside it so Replay can
+// redeliver entries a crash left unacknowledged without re-applying ones that already
+// succeeded.
+type WALBus struct {
+	*InMemoryBus
+
+	dir  string
+	file *os.File
+
+	writeMutex sync.Mutex
+
+	processedMutex sync.Mutex
+	processed      map[string]struct{}
+	processedPath  string
+}
+
+// NewWALBus opens (creating if necessary) a WAL-backed bus rooted at dir. Call Replay after
+// registering subscribers to redeliver any entries left over from a previous crash.
+func NewWALBus(dir string) (*WALBus, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	wb := &WALBus{
+		InMemoryBus:   NewInMemoryBus(),
+		dir:           dir,
+		processed:     make(map[string]struct{}),
+		processedPath: filepath.Join(dir, "processed.json"),
+	}
+
+	if err := wb.loadProcessed(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, "wal.log"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	wb.file = f
+
+	return wb, nil
+}
+
+// Publish appends msg to the WAL and fsyncs it before handing it to subscribers, so a crash
+// immediately after Publish returns still has the message durably recorded for Replay.
+func (wb *WALBus) Publish(topic string, msg Message) error {
+	wb.writeMutex.Lock()
+	line, err := json.Marshal(walRecord{Message: msg})
+	if err != nil {
+		wb.writeMutex.Unlock()
+		return err
+	}
+	if _, err := wb.file.Write(append(line, '\n')); err != nil {
+		wb.writeMutex.Unlock()
+		return err
+	}
+	err = wb.file.Sync()
+	wb.writeMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return wb.InMemoryBus.Publish(topic, msg)
+}
+
+// Subscribe wraps handler so messages whose ID is already in the processed-set are skipped,
+// making replay-after-crash safe for handlers that would otherwise double-apply side effects.
+func (wb *WALBus) Subscribe(topic string, handler HandlerFunc) error {
+	return wb.InMemoryBus.Subscribe(topic, wb.idempotent(handler))
+}
+
+func (wb *WALBus) idempotent(handler HandlerFunc) HandlerFunc {
+	return func(msg Message) error {
+		if wb.isProcessed(msg.ID) {
+			return nil
+		}
+		if err := handler(msg); err != nil {
+			return err
+		}
+		return wb.markProcessed(msg.ID)
+	}
+}
+
+// Replay re-delivers every WAL entry not yet in the processed-set. Call it once at startup,
+// after all subscribers are registered, to resume work interrupted by a crash.
+func (wb *WALBus) Replay() error {
+	wb.writeMutex.Lock()
+	if _, err := wb.file.Seek(0, 0); err != nil {
+		wb.writeMutex.Unlock()
+		return err
+	}
+
+	scanner := bufio.NewScanner(wb.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var records []walRecord
+	for scanner.Scan() {
+		var rec walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	scanErr := scanner.Err()
+
+	if _, err := wb.file.Seek(0, 2); err != nil {
+		wb.writeMutex.Unlock()
+		return err
+	}
+	wb.writeMutex.Unlock()
+
+	if scanErr != nil {
+		return scanErr
+	}
+
+	for _, rec := range records {
+		if wb.isProcessed(rec.Message.ID) {
+			continue
+		}
+		if err := wb.InMemoryBus.Publish(rec.Message.Topic, rec.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wb *WALBus) isProcessed(id string) bool {
+	wb.processedMutex.Lock()
+	defer wb.processedMutex.Unlock()
+	_, ok := wb.processed[id]
+	return ok
+}
+
+func (wb *WALBus) markProcessed(id string) error {
+	wb.processedMutex.Lock()
+	wb.processed[id] = struct{}{}
+	keys := make([]string, 0, len(wb.processed))
+	for k := range wb.processed {
+		keys = append(keys, k)
+	}
+	wb.processedMutex.Unlock()
+
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(wb.processedPath, data, 0o644)
+}
+
+func (wb *WALBus) loadProcessed() error {
+	data, err := os.ReadFile(wb.processedPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		wb.processed[k] = struct{}{}
+	}
+	return nil
+}
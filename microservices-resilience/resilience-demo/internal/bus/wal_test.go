@@ -0,0 +1,150 @@
+package bus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWALBusDeliversPublishedMessage(t *testing.T) {
+	wb, err := NewWALBus(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewWALBus: %v", err)
+	}
+
+	received := make(chan Message, 1)
+	if err := wb.Subscribe("orders", func(msg Message) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	want := Message{ID: "1", Topic: "orders", Payload: []byte("hello"), Timestamp: time.Now()}
+	if err := wb.Publish("orders", want); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.ID != want.ID {
+			t.Fatalf("got message ID %q, want %q", got.ID, want.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+}
+
+// TestWALBusReplayRedeliversUnprocessedMessages pins the crash-recovery invariant the WAL
+// exists for: a message published before a crash (so never marked processed) is redelivered
+// by Replay once a fresh WALBus is opened against the same directory.
+func TestWALBusReplayRedeliversUnprocessedMessages(t *testing.T) {
+	dir := t.TempDir()
+
+	wb1, err := NewWALBus(dir)
+	if err != nil {
+		t.Fatalf("NewWALBus: %v", err)
+	}
+	// No subscriber registered before Publish, simulating a crash before the message was
+	// ever handled: it's durably in the WAL but was never marked processed.
+	if err := wb1.Publish("orders", Message{ID: "1", Topic: "orders", Payload: []byte("a")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	wb2, err := NewWALBus(dir)
+	if err != nil {
+		t.Fatalf("re-opening NewWALBus: %v", err)
+	}
+
+	received := make(chan Message, 1)
+	if err := wb2.Subscribe("orders", func(msg Message) error {
+		received <- msg
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if err := wb2.Replay(); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.ID != "1" {
+			t.Fatalf("got message ID %q, want %q", got.ID, "1")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replayed delivery")
+	}
+}
+
+// TestWALBusReplaySkipsAlreadyProcessedMessages ensures a message whose handler already ran
+// to completion (and was marked processed) is not redelivered by Replay, which would
+// otherwise double-apply a handler's side effects (e.g. reserving inventory twice).
+func TestWALBusReplaySkipsAlreadyProcessedMessages(t *testing.T) {
+	dir := t.TempDir()
+
+	wb1, err := NewWALBus(dir)
+	if err != nil {
+		t.Fatalf("NewWALBus: %v", err)
+	}
+
+	handled := 0
+	if err := wb1.Subscribe("orders", func(msg Message) error {
+		handled++
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := wb1.Publish("orders", Message{ID: "1", Topic: "orders", Payload: []byte("a")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Subscribe's idempotent wrapper marks the message processed asynchronously (the in-memory
+	// bus dispatches on its own goroutine); give it a moment to land before reopening.
+	deadline := time.Now().Add(time.Second)
+	for handled == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if handled != 1 {
+		t.Fatalf("handler ran %d times before reopening, want exactly 1", handled)
+	}
+
+	wb2, err := NewWALBus(dir)
+	if err != nil {
+		t.Fatalf("re-opening NewWALBus: %v", err)
+	}
+
+	replayed := 0
+	if err := wb2.Subscribe("orders", func(msg Message) error {
+		replayed++
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := wb2.Replay(); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	// Give any (incorrect) redelivery a moment to land before asserting it didn't happen.
+	time.Sleep(50 * time.Millisecond)
+	if replayed != 0 {
+		t.Fatalf("got %d replayed deliveries for an already-processed message, want 0", replayed)
+	}
+}
+
+func TestWALBusPersistsLogUnderDir(t *testing.T) {
+	dir := t.TempDir()
+	wb, err := NewWALBus(dir)
+	if err != nil {
+		t.Fatalf("NewWALBus: %v", err)
+	}
+	if err := wb.Publish("orders", Message{ID: "1", Topic: "orders"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "wal.log")); err != nil {
+		t.Fatalf("expected wal.log to exist: %v", err)
+	}
+}
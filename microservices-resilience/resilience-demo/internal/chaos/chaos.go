@@ -0,0 +1,367 @@
+// Package chaos implements a configurable, typed fault-injection subsystem, applied as a
+// Gin middleware so every endpoint gets the same treatment instead of each handler calling
+// an ad-hoc simulateChaos() helper.
+package chaos
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashendes/resilience-demo/internal/metrics"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// FaultType is the kind of fault a Rule injects.
+type FaultType string
+
+const (
+	FaultError             FaultType = "error"
+	FaultLatency           FaultType = "latency"
+	FaultAbort             FaultType = "abort"
+	FaultBandwidth         FaultType = "bandwidth"
+	FaultPayloadCorruption FaultType = "payload_corruption"
+)
+
+// Fault describes a single injected fault and the fields its type needs.
+type Fault struct {
+	Type        FaultType `json:"type"`
+	Probability float64   `json:"probability"`
+
+	// FaultError
+	Status int `json:"status,omitempty"`
+
+	// FaultLatency
+	Distribution string  `json:"distribution,omitempty"` // "fixed" (default), "normal", "uniform", or "pareto"
+	MeanMs       float64 `json:"mean_ms,omitempty"`      // fixed, normal
+	StdDevMs     float64 `json:"stddev_ms,omitempty"`    // normal
+	MinMs        float64 `json:"min_ms,omitempty"`       // uniform
+	MaxMs        float64 `json:"max_ms,omitempty"`       // uniform
+	ShapeMs      float64 `json:"shape,omitempty"`        // pareto (alpha)
+	ScaleMs      float64 `json:"scale_ms,omitempty"`     // pareto (minimum latency)
+
+	// FaultBandwidth
+	BytesPerSecond int `json:"bytes_per_second,omitempty"`
+}
+
+// Rule matches requests by method + path glob and applies Fault with Fault.Probability.
+type Rule struct {
+	Name     string `json:"name,omitempty"`
+	Endpoint string `json:"endpoint"`
+	Method   string `json:"method,omitempty"` // empty or "*" matches any method
+	Fault    Fault  `json:"fault"`
+}
+
+// Config is the JSON profile accepted by POST /chaos/config.
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Scenario is the JSON payload accepted by POST /chaos/scenario: a rule set that, unlike
+// Config, auto-expires after DurationSeconds (0 means it never expires) and can be seeded for
+// reproducible runs, so the same scenario always trips the same faults at the same points —
+// needed to build a conformance-style test vector corpus against the circuit breaker and
+// bulkhead.
+type Scenario struct {
+	Rules           []Rule `json:"rules"`
+	DurationSeconds int    `json:"duration_seconds,omitempty"`
+	Seed            *int64 `json:"seed,omitempty"`
+
+	// ActivatedAt and ExpiresAt are set by the injector and reported back by Scenario(), not
+	// accepted on input.
+	ActivatedAt time.Time  `json:"activated_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// FaultInjector applies a runtime-configurable set of Rules to every request that passes
+// through its Middleware, matched by method+path glob and composed in declared order.
+type FaultInjector struct {
+	service string
+
+	mutex    sync.RWMutex
+	scenario Scenario
+
+	rngMutex sync.Mutex
+	rng      *rand.Rand
+}
+
+// NewFaultInjector creates an empty fault injector for service (no faults until SetScenario).
+func NewFaultInjector(service string) *FaultInjector {
+	return &FaultInjector{service: service, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// SetConfig replaces the active rule set with one that never expires, naming any rule left
+// anonymous. It is equivalent to SetScenario(Scenario{Rules: cfg.Rules}).
+func (fi *FaultInjector) SetConfig(cfg Config) {
+	fi.SetScenario(Scenario{Rules: cfg.Rules})
+}
+
+// Config returns the active rule set, ignoring expiry and seed.
+func (fi *FaultInjector) Config() Config {
+	return Config{Rules: fi.Scenario().Rules}
+}
+
+// SetScenario replaces the active scenario, naming any rule left anonymous and reseeding the
+// injector's random source so Seed (if set) deterministically reproduces the same sequence of
+// fault decisions and latency draws.
+func (fi *FaultInjector) SetScenario(s Scenario) {
+	for i := range s.Rules {
+		if s.Rules[i].Name == "" {
+			s.Rules[i].Name = fmt.Sprintf("rule-%d", i)
+		}
+	}
+
+	seed := time.Now().UnixNano()
+	if s.Seed != nil {
+		seed = *s.Seed
+	}
+
+	s.ActivatedAt = time.Now()
+	if s.DurationSeconds > 0 {
+		expiresAt := s.ActivatedAt.Add(time.Duration(s.DurationSeconds) * time.Second)
+		s.ExpiresAt = &expiresAt
+	}
+
+	fi.mutex.Lock()
+	fi.scenario = s
+	fi.mutex.Unlock()
+
+	fi.rngMutex.Lock()
+	fi.rng = rand.New(rand.NewSource(seed))
+	fi.rngMutex.Unlock()
+}
+
+// Scenario returns the active scenario. A scenario past its ExpiresAt is reported with its
+// rules already cleared, matching what rulesFor will inject.
+func (fi *FaultInjector) Scenario() Scenario {
+	fi.mutex.RLock()
+	defer fi.mutex.RUnlock()
+
+	if fi.expired(fi.scenario) {
+		return Scenario{}
+	}
+	return fi.scenario
+}
+
+// Clear is the global kill switch: it removes every active rule.
+func (fi *FaultInjector) Clear() {
+	fi.mutex.Lock()
+	defer fi.mutex.Unlock()
+	fi.scenario = Scenario{}
+}
+
+func (fi *FaultInjector) expired(s Scenario) bool {
+	return s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt)
+}
+
+func (fi *FaultInjector) rulesFor(method, path string) []Rule {
+	fi.mutex.RLock()
+	defer fi.mutex.RUnlock()
+
+	if fi.expired(fi.scenario) {
+		return nil
+	}
+
+	var matched []Rule
+	for _, rule := range fi.scenario.Rules {
+		if rule.Method != "" && rule.Method != "*" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+		if !matchGlob(rule.Endpoint, path) {
+			continue
+		}
+		matched = append(matched, rule)
+	}
+	return matched
+}
+
+// float64 and normFloat64 draw from the injector's own random source (rather than the
+// math/rand package-level one) so a seeded Scenario reproduces the same fault decisions and
+// latency draws every run.
+func (fi *FaultInjector) float64() float64 {
+	fi.rngMutex.Lock()
+	defer fi.rngMutex.Unlock()
+	return fi.rng.Float64()
+}
+
+func (fi *FaultInjector) normFloat64() float64 {
+	fi.rngMutex.Lock()
+	defer fi.rngMutex.Unlock()
+	return fi.rng.NormFloat64()
+}
+
+// matchGlob supports a single trailing "/*" segment (e.g. "/inventory/check/*"); anything
+// else must match the path exactly.
+func matchGlob(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := strings.TrimSuffix(pattern, "/*")
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return pattern == path
+}
+
+// Middleware applies every matching rule, in declared order, to each request.
+func (fi *FaultInjector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		var corrupt *corruptingWriter
+		for _, rule := range fi.rulesFor(c.Request.Method, path) {
+			if fi.float64() >= rule.Fault.Probability {
+				continue
+			}
+			metrics.ChaosFaultInjected.WithLabelValues(fi.service, rule.Name, string(rule.Fault.Type)).Inc()
+
+			switch rule.Fault.Type {
+			case FaultLatency:
+				time.Sleep(fi.latencyFor(rule.Fault))
+
+			case FaultError:
+				status := rule.Fault.Status
+				if status == 0 {
+					status = http.StatusServiceUnavailable
+				}
+				c.AbortWithStatusJSON(status, gin.H{
+					"error": "chaos: injected failure",
+					"rule":  rule.Name,
+				})
+				return
+
+			case FaultAbort:
+				log.WithField("rule", rule.Name).Warn("chaos: aborting connection mid-response")
+				hijackAndClose(c)
+				return
+
+			case FaultBandwidth:
+				c.Writer = newThrottledWriter(c.Writer, rule.Fault.BytesPerSecond)
+
+			case FaultPayloadCorruption:
+				corrupt = newCorruptingWriter(c.Writer)
+				c.Writer = corrupt
+			}
+		}
+
+		c.Next()
+
+		if corrupt != nil {
+			corrupt.flush()
+		}
+	}
+}
+
+// latencyFor draws a latency sample for f's distribution, using fi's seeded random source so a
+// Scenario with a Seed reproduces the same latencies every run.
+func (fi *FaultInjector) latencyFor(f Fault) time.Duration {
+	switch f.Distribution {
+	case "normal":
+		ms := fi.normFloat64()*f.StdDevMs + f.MeanMs
+		if ms < 0 {
+			ms = 0
+		}
+		return time.Duration(ms) * time.Millisecond
+
+	case "uniform":
+		ms := f.MinMs + fi.float64()*(f.MaxMs-f.MinMs)
+		return time.Duration(ms) * time.Millisecond
+
+	case "pareto":
+		// Inverse transform sampling of a Pareto(ShapeMs, ScaleMs) distribution: ScaleMs is
+		// the minimum latency and ShapeMs (alpha) controls how heavy the tail is.
+		shape := f.ShapeMs
+		if shape <= 0 {
+			shape = 1
+		}
+		ms := f.ScaleMs / math.Pow(fi.float64(), 1/shape)
+		return time.Duration(ms) * time.Millisecond
+
+	default: // "fixed" or unset
+		return time.Duration(f.MeanMs) * time.Millisecond
+	}
+}
+
+// hijackAndClose implements the "abort" fault: close the connection mid-response, without
+// writing a status line or body, the way a crashed upstream would.
+func hijackAndClose(c *gin.Context) {
+	c.Abort()
+
+	hijacker, ok := c.Writer.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	conn.Close()
+}
+
+// throttledWriter wraps gin.ResponseWriter to cap write throughput, simulating a slow link.
+type throttledWriter struct {
+	gin.ResponseWriter
+	bytesPerSecond int
+}
+
+func newThrottledWriter(w gin.ResponseWriter, bytesPerSecond int) gin.ResponseWriter {
+	if bytesPerSecond <= 0 {
+		bytesPerSecond = 1024
+	}
+	return &throttledWriter{ResponseWriter: w, bytesPerSecond: bytesPerSecond}
+}
+
+func (w *throttledWriter) Write(data []byte) (int, error) {
+	const chunkSize = 256
+
+	total := 0
+	for total < len(data) {
+		end := total + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		n, err := w.ResponseWriter.Write(data[total:end])
+		total += n
+		if err != nil {
+			return total, err
+		}
+
+		time.Sleep(time.Duration(float64(n) / float64(w.bytesPerSecond) * float64(time.Second)))
+	}
+	return total, nil
+}
+
+// corruptingWriter buffers the response body and flips one byte before writing it through,
+// simulating bit-level corruption in transit. The middleware flushes it once the handler
+// has finished writing.
+type corruptingWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func newCorruptingWriter(w gin.ResponseWriter) *corruptingWriter {
+	return &corruptingWriter{ResponseWriter: w}
+}
+
+func (w *corruptingWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+func (w *corruptingWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+func (w *corruptingWriter) flush() {
+	body := w.buf.Bytes()
+	if len(body) > 0 {
+		body[rand.Intn(len(body))] ^= 0xFF
+	}
+	w.ResponseWriter.Write(body)
+}
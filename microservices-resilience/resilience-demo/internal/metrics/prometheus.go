@@ -92,22 +92,78 @@ var (
 		},
 	)
 
-	// ChaosFailureRate tracks chaos engineering failure simulations
-	ChaosFailureRate = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "chaos_failure_enabled",
-			Help: "Whether chaos failure mode is enabled (1=enabled, 0=disabled)",
+	// ChaosFaultInjected tracks faults injected by the internal/chaos FaultInjector, per rule
+	ChaosFaultInjected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chaos_fault_injected_total",
+			Help: "Total number of faults injected by the chaos fault injector",
+		},
+		[]string{"service", "rule_name", "fault_type"},
+	)
+
+	// RateLimiterAllowed tracks requests allowed through a rate limiter
+	RateLimiterAllowed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limiter_allowed_total",
+			Help: "Total number of requests allowed by a rate limiter",
+		},
+		[]string{"service", "limiter_name"},
+	)
+
+	// RateLimiterRejected tracks requests rejected by a rate limiter
+	RateLimiterRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limiter_rejected_total",
+			Help: "Total number of requests rejected by a rate limiter",
 		},
-		[]string{"service"},
+		[]string{"service", "limiter_name"},
 	)
 
-	// ChaosSlowMode tracks slow response simulation
-	ChaosSlowMode = promauto.NewGaugeVec(
+	// RateLimiterTokensAvailable tracks tokens available for a tracked key bucket
+	RateLimiterTokensAvailable = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "chaos_slow_mode_enabled",
-			Help: "Whether chaos slow mode is enabled (1=enabled, 0=disabled)",
+			Name: "rate_limiter_tokens_available",
+			Help: "Tokens currently available, for the top tracked key buckets",
+		},
+		[]string{"service", "limiter_name", "key_bucket"},
+	)
+
+	// RetryAttempts tracks retry outcomes
+	RetryAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retry_attempts_total",
+			Help: "Total number of retry attempts by outcome",
+		},
+		[]string{"service", "retrier", "outcome"},
+	)
+
+	// RetryBackoff tracks the backoff duration slept between retry attempts
+	RetryBackoff = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "retry_backoff_seconds",
+			Help:    "Backoff duration slept between retry attempts, in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"service", "retrier"},
+	)
+
+	// SagaStepTotal tracks saga step outcomes
+	SagaStepTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "saga_step_total",
+			Help: "Total number of saga steps by outcome",
+		},
+		[]string{"service", "step", "outcome"},
+	)
+
+	// SagaDuration tracks how long a saga step took to execute
+	SagaDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "saga_duration_seconds",
+			Help:    "Saga step duration in seconds",
+			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"service"},
+		[]string{"service", "step"},
 	)
 )
 
@@ -43,3 +43,23 @@ type ReleaseItemsResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
 }
+
+// OrderCreatedEvent is published on the "order.created" topic to kick off the inventory
+// reservation saga.
+type OrderCreatedEvent struct {
+	OrderID string      `json:"order_id"`
+	Items   []OrderItem `json:"items"`
+}
+
+// InventoryReservedEvent is published on the "inventory.reserved" topic once a saga step
+// reserves stock successfully.
+type InventoryReservedEvent struct {
+	OrderID string `json:"order_id"`
+}
+
+// InventoryReservationFailedEvent is published on the "inventory.reservation_failed" topic
+// when a saga step cannot reserve stock, so the order saga can run its compensating action.
+type InventoryReservationFailedEvent struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason"`
+}
@@ -11,11 +11,12 @@ type OrderItem struct {
 
 // Order represents a customer order
 type Order struct {
-	ID          string      `json:"id"`
-	Items       []OrderItem `json:"items" binding:"required,dive"`
-	TotalAmount float64     `json:"total_amount"`
-	Status      string      `json:"status"`
-	Timestamp   time.Time   `json:"timestamp"`
+	ID            string      `json:"id"`
+	Items         []OrderItem `json:"items" binding:"required,dive"`
+	TotalAmount   float64     `json:"total_amount"`
+	Status        string      `json:"status"`
+	Timestamp     time.Time   `json:"timestamp"`
+	TransactionID string      `json:"transaction_id,omitempty"`
 }
 
 // OrderStatus constants
@@ -13,9 +13,11 @@ type Transaction struct {
 
 // TransactionStatus constants
 const (
-	TransactionStatusPending   = "pending"
-	TransactionStatusCompleted = "completed"
-	TransactionStatusFailed    = "failed"
+	TransactionStatusPending    = "pending"
+	TransactionStatusProcessing = "processing"
+	TransactionStatusCompleted  = "completed"
+	TransactionStatusFailed     = "failed"
+	TransactionStatusRefunded   = "refunded"
 )
 
 // ChargeRequest represents a payment charge request
@@ -30,3 +32,16 @@ type ChargeResponse struct {
 	Status        string `json:"status"`
 	Message       string `json:"message,omitempty"`
 }
+
+// RefundRequest represents a request to reverse a completed charge
+type RefundRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// RefundResponse represents the response after refunding a transaction
+type RefundResponse struct {
+	TransactionID string `json:"transaction_id"`
+	Status        string `json:"status"`
+	Message       string `json:"message,omitempty"`
+}
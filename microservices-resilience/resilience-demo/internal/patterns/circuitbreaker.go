@@ -91,7 +91,7 @@ func (cb *CircuitBreakerWrapper) GetStateValue() int {
 	}
 }
 
-// FormatError formats an error message with circuit breaker info
+// FormatError formats an error message with circuit breaker (or other pattern) info
 func FormatError(circuitName string, err error) error {
 	if err == gobreaker.ErrOpenState {
 		return fmt.Errorf("circuit breaker %s is open (service unavailable)", circuitName)
@@ -99,5 +99,8 @@ func FormatError(circuitName string, err error) error {
 	if err == gobreaker.ErrTooManyRequests {
 		return fmt.Errorf("circuit breaker %s: too many requests in half-open state", circuitName)
 	}
+	if err == ErrRateLimited {
+		return fmt.Errorf("rate limiter %s: request rejected", circuitName)
+	}
 	return err
 }
@@ -0,0 +1,238 @@
+package patterns
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ashendes/resilience-demo/internal/metrics"
+)
+
+// RateLimiterAlgorithm selects how a RateLimiter admits requests.
+type RateLimiterAlgorithm string
+
+const (
+	// TokenBucket refills tokens continuously up to a burst ceiling.
+	TokenBucket RateLimiterAlgorithm = "token_bucket"
+	// LeakyBucket models a fixed-rate drain queue.
+	LeakyBucket RateLimiterAlgorithm = "leaky_bucket"
+	// SlidingWindow sums second-granularity counters over a trailing window.
+	SlidingWindow RateLimiterAlgorithm = "sliding_window"
+)
+
+const (
+	rateLimiterShardCount  = 32
+	rateLimiterTrackedKeys = 100 // top-N keys whose gauge we track, to avoid cardinality explosion
+)
+
+// ErrRateLimited is returned by Execute when the caller has exceeded its rate limit.
+var ErrRateLimited = errors.New("rate limiter: request rejected")
+
+// KeyFunc resolves the rate-limiting identity (order ID, IP, API key, ...) for a request.
+type KeyFunc func(ctx context.Context) string
+
+// RateLimiterConfig configures a RateLimiter.
+type RateLimiterConfig struct {
+	Algorithm RateLimiterAlgorithm
+	// Rate is tokens-per-second for TokenBucket, drain-per-second for LeakyBucket, and
+	// requests-per-second for SlidingWindow.
+	Rate float64
+	// Burst is the max tokens held (TokenBucket) or the queue depth (LeakyBucket).
+	Burst float64
+	// Window is the trailing window summed by SlidingWindow. Defaults to 10s.
+	Window time.Duration
+	// KeyFunc resolves the identity to rate-limit by. Defaults to a single global key.
+	KeyFunc KeyFunc
+}
+
+type windowSlot struct {
+	second int64
+	count  int
+}
+
+type bucketState struct {
+	tokens     float64
+	lastRefill time.Time
+	queued     float64
+	window     []windowSlot
+}
+
+type rateLimiterShard struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucketState
+}
+
+// RateLimiter implements token-bucket, leaky-bucket, and sliding-window rate limiting,
+// sharded by key so hot keys don't serialize against each other.
+type RateLimiter struct {
+	name    string
+	service string
+	cfg     RateLimiterConfig
+	shards  [rateLimiterShardCount]*rateLimiterShard
+
+	lruMutex sync.Mutex
+	lru      *list.List
+	lruIndex map[string]*list.Element
+}
+
+// NewRateLimiter creates a new rate limiter with Prometheus metrics.
+func NewRateLimiter(name, service string, cfg RateLimiterConfig) *RateLimiter {
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = func(ctx context.Context) string { return "global" }
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+
+	rl := &RateLimiter{
+		name:     name,
+		service:  service,
+		cfg:      cfg,
+		lru:      list.New(),
+		lruIndex: make(map[string]*list.Element),
+	}
+
+	for i := range rl.shards {
+		rl.shards[i] = &rateLimiterShard{buckets: make(map[string]*bucketState)}
+	}
+
+	return rl
+}
+
+// Execute resolves the caller's identity via KeyFunc and either runs fn or returns
+// ErrRateLimited.
+func (rl *RateLimiter) Execute(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	key := rl.cfg.KeyFunc(ctx)
+
+	allowed, tokensLeft := rl.allow(key)
+	rl.trackKey(key, tokensLeft)
+
+	if !allowed {
+		metrics.RateLimiterRejected.WithLabelValues(rl.service, rl.name).Inc()
+		return nil, ErrRateLimited
+	}
+
+	metrics.RateLimiterAllowed.WithLabelValues(rl.service, rl.name).Inc()
+	return fn()
+}
+
+// shardFor picks a shard by the FNV hash of the key, so hot keys spread across shards.
+func (rl *RateLimiter) shardFor(key string) *rateLimiterShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return rl.shards[h.Sum32()%rateLimiterShardCount]
+}
+
+func (rl *RateLimiter) allow(key string) (bool, float64) {
+	shard := rl.shardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	b, ok := shard.buckets[key]
+	if !ok {
+		b = &bucketState{tokens: rl.cfg.Burst, lastRefill: time.Now()}
+		shard.buckets[key] = b
+	}
+
+	switch rl.cfg.Algorithm {
+	case LeakyBucket:
+		return rl.allowLeaky(b)
+	case SlidingWindow:
+		return rl.allowSlidingWindow(b)
+	default:
+		return rl.allowTokenBucket(b)
+	}
+}
+
+// allowTokenBucket refills tokens = min(burst, tokens + elapsed*rate) and admits if at
+// least one token is available.
+func (rl *RateLimiter) allowTokenBucket(b *bucketState) (bool, float64) {
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens = math.Min(rl.cfg.Burst, b.tokens+elapsed*rl.cfg.Rate)
+	b.lastRefill = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, b.tokens
+	}
+	return false, b.tokens
+}
+
+// allowLeaky models the queue draining at cfg.Rate per second and rejects once admitting
+// the request would push the queue past cfg.Burst (its depth).
+func (rl *RateLimiter) allowLeaky(b *bucketState) (bool, float64) {
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.queued = math.Max(0, b.queued-elapsed*rl.cfg.Rate)
+	b.lastRefill = now
+
+	if b.queued+1 <= rl.cfg.Burst {
+		b.queued++
+		return true, rl.cfg.Burst - b.queued
+	}
+	return false, rl.cfg.Burst - b.queued
+}
+
+// allowSlidingWindow sums one-second buckets over the trailing window and rejects once the
+// total would exceed cfg.Rate * window seconds.
+func (rl *RateLimiter) allowSlidingWindow(b *bucketState) (bool, float64) {
+	windowSeconds := int64(rl.cfg.Window.Seconds())
+	if windowSeconds < 1 {
+		windowSeconds = 1
+	}
+	if b.window == nil {
+		b.window = make([]windowSlot, windowSeconds)
+	}
+
+	now := time.Now().Unix()
+	total := 0
+	for i := range b.window {
+		slot := &b.window[i]
+		if now-slot.second >= windowSeconds {
+			slot.count = 0
+			slot.second = now
+		}
+		total += slot.count
+	}
+
+	limit := rl.cfg.Rate * float64(windowSeconds)
+	if float64(total) >= limit {
+		return false, limit - float64(total)
+	}
+
+	slot := &b.window[now%windowSeconds]
+	if slot.second != now {
+		slot.second = now
+		slot.count = 0
+	}
+	slot.count++
+
+	return true, limit - float64(total) - 1
+}
+
+// trackKey maintains an LRU of the top tracked keys so the per-key gauge doesn't blow up
+// cardinality on high-fanout identities; evicted keys have their gauge series removed.
+func (rl *RateLimiter) trackKey(key string, tokensLeft float64) {
+	rl.lruMutex.Lock()
+	defer rl.lruMutex.Unlock()
+
+	if el, ok := rl.lruIndex[key]; ok {
+		rl.lru.MoveToFront(el)
+	} else {
+		if rl.lru.Len() >= rateLimiterTrackedKeys {
+			if oldest := rl.lru.Back(); oldest != nil {
+				evicted := rl.lru.Remove(oldest).(string)
+				delete(rl.lruIndex, evicted)
+				metrics.RateLimiterTokensAvailable.DeleteLabelValues(rl.service, rl.name, evicted)
+			}
+		}
+		rl.lruIndex[key] = rl.lru.PushFront(key)
+	}
+
+	metrics.RateLimiterTokensAvailable.WithLabelValues(rl.service, rl.name, key).Set(tokensLeft)
+}
@@ -0,0 +1,113 @@
+package patterns
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTokenBucketAllowsBurstThenRejects(t *testing.T) {
+	rl := NewRateLimiter("test", "test-service", RateLimiterConfig{
+		Algorithm: TokenBucket,
+		Rate:      1,
+		Burst:     2,
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil })
+		if err != nil {
+			t.Fatalf("request %d within burst: got error %v, want nil", i, err)
+		}
+	}
+
+	_, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil })
+	if err != ErrRateLimited {
+		t.Fatalf("request past burst: got %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimiterTokenBucketRefillsOverTime(t *testing.T) {
+	rl := NewRateLimiter("test", "test-service", RateLimiterConfig{
+		Algorithm: TokenBucket,
+		Rate:      1000,
+		Burst:     1,
+	})
+
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("first request: got error %v, want nil", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("request after refill window: got error %v, want nil", err)
+	}
+}
+
+func TestRateLimiterLeakyBucketRejectsPastQueueDepth(t *testing.T) {
+	rl := NewRateLimiter("test", "test-service", RateLimiterConfig{
+		Algorithm: LeakyBucket,
+		Rate:      0,
+		Burst:     1,
+	})
+
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("first request: got error %v, want nil", err)
+	}
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != ErrRateLimited {
+		t.Fatalf("second request over queue depth: got %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimiterSlidingWindowRejectsPastRate(t *testing.T) {
+	rl := NewRateLimiter("test", "test-service", RateLimiterConfig{
+		Algorithm: SlidingWindow,
+		Rate:      1,
+		Window:    time.Second,
+	})
+
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("first request: got error %v, want nil", err)
+	}
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != ErrRateLimited {
+		t.Fatalf("second request over window rate: got %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimiterKeyFuncIsolatesBuckets(t *testing.T) {
+	callerKey := "caller-a"
+	rl := NewRateLimiter("test", "test-service", RateLimiterConfig{
+		Algorithm: TokenBucket,
+		Rate:      0,
+		Burst:     1,
+		KeyFunc:   func(ctx context.Context) string { return callerKey },
+	})
+
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("caller-a first request: got error %v, want nil", err)
+	}
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != ErrRateLimited {
+		t.Fatalf("caller-a second request: got %v, want ErrRateLimited", err)
+	}
+
+	callerKey = "caller-b"
+	if _, err := rl.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("caller-b first request should have its own bucket: got error %v, want nil", err)
+	}
+}
+
+func TestRateLimiterExecuteReturnsFnResult(t *testing.T) {
+	rl := NewRateLimiter("test", "test-service", RateLimiterConfig{
+		Algorithm: TokenBucket,
+		Rate:      1,
+		Burst:     1,
+	})
+
+	result, err := rl.Execute(context.Background(), func() (interface{}, error) { return "ok", nil })
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+	if result != "ok" {
+		t.Fatalf("got result %v, want %q", result, "ok")
+	}
+}
@@ -0,0 +1,194 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ashendes/resilience-demo/internal/metrics"
+	"github.com/sony/gobreaker"
+)
+
+// RetryableFunc decides whether an error returned by the wrapped call should be retried.
+type RetryableFunc func(error) bool
+
+// RetryConfig configures a Retrier.
+type RetryConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Multiplier is the exponential backoff growth factor. Defaults to 2.0.
+	Multiplier float64
+	// JitterFraction controls how much of the backoff window is randomized, per AWS's
+	// "full jitter" guidance: 1.0 picks uniformly from [0, cap], 0.0 disables jitter
+	// entirely. nil defaults to 1.0; use a pointer so an explicit 0 can be told apart
+	// from "not set".
+	JitterFraction *float64
+	// BudgetRatio caps retries as a fraction of requests over a rolling 10s window, so a
+	// struggling dependency doesn't get amplified load from retry storms. Defaults to 0.1.
+	BudgetRatio float64
+	// RetryableFunc decides whether an error should be retried. Defaults to retrying
+	// everything except context cancellation/deadline and gobreaker.ErrOpenState.
+	RetryableFunc RetryableFunc
+}
+
+const retryBudgetWindow = 10 * time.Second
+
+type retryBudgetSlot struct {
+	second   int64
+	requests int
+	retries  int
+}
+
+// Retrier wraps a call with exponential backoff, full jitter, and a retry budget. It is
+// meant to wrap a CircuitBreaker (not the other way around) so the breaker sees the
+// aggregate pass/fail outcome of each logical call, not just its first attempt.
+type Retrier struct {
+	name    string
+	service string
+	cfg     RetryConfig
+
+	budgetMutex sync.Mutex
+	budget      [10]retryBudgetSlot
+}
+
+// NewRetrier creates a new Retrier with Prometheus metrics.
+func NewRetrier(name, service string, cfg RetryConfig) *Retrier {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Multiplier <= 0 {
+		cfg.Multiplier = 2.0
+	}
+	if cfg.JitterFraction == nil {
+		fullJitter := 1.0
+		cfg.JitterFraction = &fullJitter
+	}
+	if cfg.BudgetRatio <= 0 {
+		cfg.BudgetRatio = 0.1
+	}
+	if cfg.RetryableFunc == nil {
+		cfg.RetryableFunc = defaultRetryable
+	}
+
+	return &Retrier{name: name, service: service, cfg: cfg}
+}
+
+// defaultRetryable never retries a tripped circuit breaker or context cancellation/deadline;
+// everything else is assumed transient.
+func defaultRetryable(err error) bool {
+	if errors.Is(err, gobreaker.ErrOpenState) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return true
+}
+
+// Execute runs fn, retrying on retryable errors with exponential backoff and full jitter
+// until MaxAttempts is reached, the retry budget is exhausted, or ctx is done.
+func (rt *Retrier) Execute(ctx context.Context, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	rt.recordRequest()
+
+	var lastErr error
+	for attempt := 0; attempt < rt.cfg.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if !rt.allowRetry() {
+				metrics.RetryAttempts.WithLabelValues(rt.service, rt.name, "budget_exceeded").Inc()
+				return nil, lastErr
+			}
+			rt.recordRetry()
+
+			backoff := rt.nextBackoff(attempt)
+			metrics.RetryBackoff.WithLabelValues(rt.service, rt.name).Observe(backoff.Seconds())
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		result, err := fn(ctx)
+		if err == nil {
+			metrics.RetryAttempts.WithLabelValues(rt.service, rt.name, "success").Inc()
+			return result, nil
+		}
+
+		lastErr = err
+		if !rt.cfg.RetryableFunc(err) {
+			metrics.RetryAttempts.WithLabelValues(rt.service, rt.name, "non_retryable").Inc()
+			return nil, err
+		}
+	}
+
+	metrics.RetryAttempts.WithLabelValues(rt.service, rt.name, "exhausted").Inc()
+	return nil, lastErr
+}
+
+// nextBackoff computes capped exponential backoff with full jitter: sleep is drawn from
+// [cap*(1-JitterFraction), cap] where cap = min(MaxBackoff, InitialBackoff*Multiplier^attempt).
+func (rt *Retrier) nextBackoff(attempt int) time.Duration {
+	ceiling := float64(rt.cfg.InitialBackoff) * math.Pow(rt.cfg.Multiplier, float64(attempt))
+	if max := float64(rt.cfg.MaxBackoff); max > 0 && ceiling > max {
+		ceiling = max
+	}
+
+	floor := ceiling * (1 - *rt.cfg.JitterFraction)
+	jittered := floor + rand.Float64()*(ceiling-floor)
+
+	return time.Duration(jittered)
+}
+
+// recordRequest and recordRetry maintain a rolling 10-second window of per-second counters
+// used to enforce the retry budget.
+func (rt *Retrier) recordRequest() {
+	rt.budgetMutex.Lock()
+	defer rt.budgetMutex.Unlock()
+	rt.touchSlot(time.Now().Unix()).requests++
+}
+
+func (rt *Retrier) recordRetry() {
+	rt.budgetMutex.Lock()
+	defer rt.budgetMutex.Unlock()
+	rt.touchSlot(time.Now().Unix()).retries++
+}
+
+// allowRetry reports whether the rolling retries/requests ratio is still under BudgetRatio.
+func (rt *Retrier) allowRetry() bool {
+	rt.budgetMutex.Lock()
+	defer rt.budgetMutex.Unlock()
+
+	now := time.Now().Unix()
+	var requests, retries int
+	for i := range rt.budget {
+		slot := &rt.budget[i]
+		if now-slot.second >= int64(retryBudgetWindow.Seconds()) {
+			continue
+		}
+		requests += slot.requests
+		retries += slot.retries
+	}
+
+	if requests == 0 {
+		return true
+	}
+	return float64(retries)/float64(requests) <= rt.cfg.BudgetRatio
+}
+
+// touchSlot returns the slot for the given second, resetting it first if it has aged out
+// of the window's ring buffer.
+func (rt *Retrier) touchSlot(second int64) *retryBudgetSlot {
+	windowSeconds := int64(retryBudgetWindow.Seconds())
+	slot := &rt.budget[second%windowSeconds]
+	if now := second; now-slot.second >= windowSeconds {
+		slot.second = now
+		slot.requests = 0
+		slot.retries = 0
+	}
+	return slot
+}
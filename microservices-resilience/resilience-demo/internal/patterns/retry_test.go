@@ -0,0 +1,145 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetrierRetriesUntilSuccess(t *testing.T) {
+	rt := NewRetrier("test", "test-service", RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		BudgetRatio:    1,
+	})
+
+	attempts := 0
+	result, err := rt.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("transient")
+		}
+		return "ok", nil
+	})
+
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+	if result != "ok" {
+		t.Fatalf("got result %v, want %q", result, "ok")
+	}
+	if attempts != 3 {
+		t.Fatalf("got %d attempts, want 3", attempts)
+	}
+}
+
+func TestRetrierStopsAtMaxAttempts(t *testing.T) {
+	rt := NewRetrier("test", "test-service", RetryConfig{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		BudgetRatio:    1,
+	})
+
+	attempts := 0
+	wantErr := errors.New("permanent")
+	_, err := rt.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		return nil, wantErr
+	})
+
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("got error %v, want %v", err, wantErr)
+	}
+	if attempts != 2 {
+		t.Fatalf("got %d attempts, want MaxAttempts (2)", attempts)
+	}
+}
+
+func TestRetrierDoesNotRetryNonRetryableErrors(t *testing.T) {
+	rt := NewRetrier("test", "test-service", RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		BudgetRatio:    1,
+		RetryableFunc:  func(err error) bool { return false },
+	})
+
+	attempts := 0
+	_, err := rt.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		return nil, errors.New("do not retry me")
+	})
+
+	if err == nil {
+		t.Fatal("got nil error, want the non-retryable error")
+	}
+	if attempts != 1 {
+		t.Fatalf("got %d attempts, want 1 (no retries)", attempts)
+	}
+}
+
+// TestRetrierZeroJitterFractionIsNotOverridden pins the fix for a bug where NewRetrier
+// treated an explicit JitterFraction of 0 the same as "not set" and forced it back to 1.0,
+// making it impossible to disable jitter as the doc comment claimed.
+func TestRetrierZeroJitterFractionIsNotOverridden(t *testing.T) {
+	noJitter := 0.0
+	rt := NewRetrier("test", "test-service", RetryConfig{
+		MaxAttempts:    1,
+		JitterFraction: &noJitter,
+	})
+
+	if rt.cfg.JitterFraction == nil || *rt.cfg.JitterFraction != 0 {
+		t.Fatalf("got JitterFraction %v, want a pointer to 0", rt.cfg.JitterFraction)
+	}
+}
+
+func TestRetrierNilJitterFractionDefaultsToFullJitter(t *testing.T) {
+	rt := NewRetrier("test", "test-service", RetryConfig{MaxAttempts: 1})
+
+	if rt.cfg.JitterFraction == nil || *rt.cfg.JitterFraction != 1.0 {
+		t.Fatalf("got JitterFraction %v, want a pointer to 1.0", rt.cfg.JitterFraction)
+	}
+}
+
+func TestRetrierNextBackoffWithZeroJitterIsDeterministic(t *testing.T) {
+	noJitter := 0.0
+	rt := NewRetrier("test", "test-service", RetryConfig{
+		MaxAttempts:    1,
+		InitialBackoff: 10 * time.Millisecond,
+		Multiplier:     2,
+		JitterFraction: &noJitter,
+	})
+
+	// With jitter disabled, backoff is pinned to the ceiling rather than drawn from a range.
+	for attempt, want := range map[int]time.Duration{
+		1: 20 * time.Millisecond,
+		2: 40 * time.Millisecond,
+	} {
+		if got := rt.nextBackoff(attempt); got != want {
+			t.Errorf("attempt %d: got backoff %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestRetrierBudgetExhaustionStopsRetrying(t *testing.T) {
+	rt := NewRetrier("test", "test-service", RetryConfig{
+		MaxAttempts:    10,
+		InitialBackoff: time.Millisecond,
+		BudgetRatio:    0.01,
+	})
+
+	attempts := 0
+	_, err := rt.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+		attempts++
+		return nil, errors.New("always fails")
+	})
+
+	if err == nil {
+		t.Fatal("got nil error, want a failure")
+	}
+	// The very first retry already exceeds a 1% budget against a single request, so Execute
+	// must give up well short of MaxAttempts.
+	if attempts >= 10 {
+		t.Fatalf("got %d attempts, want fewer than MaxAttempts due to budget exhaustion", attempts)
+	}
+}
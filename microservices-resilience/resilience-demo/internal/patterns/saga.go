@@ -0,0 +1,303 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ashendes/resilience-demo/internal/metrics"
+)
+
+// StepStatus is the durable state of a single saga step.
+type StepStatus string
+
+const (
+	StepPending      StepStatus = "pending"
+	StepCompleted    StepStatus = "completed"
+	StepCompensating StepStatus = "compensating"
+	StepCompensated  StepStatus = "compensated"
+	StepFailed       StepStatus = "failed"
+)
+
+// StepRecord is the persisted state of one step within a SagaState.
+type StepRecord struct {
+	Name   string     `json:"name"`
+	Status StepStatus `json:"status"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// SagaState is the durable state of one saga run, keyed by SagaID (the order ID). Payload is
+// the caller's input to Run, persisted so Resume can rebuild the same Steps (and the closures'
+// captured arguments) after a restart without the caller having to remember what was in flight.
+type SagaState struct {
+	SagaID  string          `json:"saga_id"`
+	Steps   []StepRecord    `json:"steps"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// SagaStore persists SagaState so a crashed saga can be inspected, and manually re-driven,
+// instead of leaving orphaned side effects (e.g. inventory reserved but never paid for).
+type SagaStore interface {
+	Save(state *SagaState) error
+	Load(sagaID string) (*SagaState, bool, error)
+	List() ([]*SagaState, error)
+}
+
+// InMemorySagaStore is a SagaStore with no durability, useful for tests and local runs
+// without a writable data directory.
+type InMemorySagaStore struct {
+	mutex  sync.RWMutex
+	states map[string]*SagaState
+}
+
+// NewInMemorySagaStore creates an empty in-memory saga store.
+func NewInMemorySagaStore() *InMemorySagaStore {
+	return &InMemorySagaStore{states: make(map[string]*SagaState)}
+}
+
+func (s *InMemorySagaStore) Save(state *SagaState) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.states[state.SagaID] = state
+	return nil
+}
+
+func (s *InMemorySagaStore) Load(sagaID string) (*SagaState, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	state, ok := s.states[sagaID]
+	return state, ok, nil
+}
+
+func (s *InMemorySagaStore) List() ([]*SagaState, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	states := make([]*SagaState, 0, len(s.states))
+	for _, state := range s.states {
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// FileSagaStore persists one JSON file per saga under dir, so a saga's state survives a
+// process restart and can be replayed to find orders a crash left mid-flight.
+type FileSagaStore struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// NewFileSagaStore opens (creating if necessary) a file-backed saga store rooted at dir.
+func NewFileSagaStore(dir string) (*FileSagaStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSagaStore{dir: dir}, nil
+}
+
+func (s *FileSagaStore) path(sagaID string) string {
+	return filepath.Join(s.dir, sagaID+".json")
+}
+
+func (s *FileSagaStore) Save(state *SagaState) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(state.SagaID), data, 0o644)
+}
+
+func (s *FileSagaStore) Load(sagaID string) (*SagaState, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := os.ReadFile(s.path(sagaID))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var state SagaState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, false, err
+	}
+	return &state, true, nil
+}
+
+func (s *FileSagaStore) List() ([]*SagaState, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []*SagaState
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var state SagaState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, err
+		}
+		states = append(states, &state)
+	}
+	return states, nil
+}
+
+// Step is one stage of a Saga: Action performs it, and Compensate (optional — nil for a
+// step with nothing to undo) reverses it if a later step in the same saga fails.
+type Step struct {
+	Name       string
+	Action     func() error
+	Compensate func() error
+}
+
+// Saga runs a sequence of Steps, persisting each step's state transition to a SagaStore so
+// the run can be inspected after a crash instead of leaving orphaned side effects. If a step
+// fails, previously completed steps are compensated in reverse order.
+type Saga struct {
+	store   SagaStore
+	service string
+}
+
+// NewSaga creates a Saga that persists to store and labels its metrics with service.
+func NewSaga(store SagaStore, service string) *Saga {
+	return &Saga{store: store, service: service}
+}
+
+// Store returns the SagaStore backing this Saga, e.g. for a GET /order/:id/saga handler.
+func (s *Saga) Store() SagaStore {
+	return s.store
+}
+
+// Run executes steps in order under sagaID, persisting state after every transition. payload
+// is marshaled and persisted alongside the run (e.g. the order being processed) so Resume can
+// later rebuild the same Steps after a crash; pass nil if there's nothing to resume with. If a
+// step fails, Run compensates the previously completed steps in reverse order and then
+// returns the original step's error.
+func (s *Saga) Run(sagaID string, steps []Step, payload interface{}) error {
+	state := &SagaState{SagaID: sagaID}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("saga: failed to marshal payload: %w", err)
+		}
+		state.Payload = data
+	}
+	for _, step := range steps {
+		state.Steps = append(state.Steps, StepRecord{Name: step.Name, Status: StepPending})
+	}
+	if err := s.store.Save(state); err != nil {
+		return fmt.Errorf("saga: failed to persist initial state: %w", err)
+	}
+
+	return s.run(state, steps, 0)
+}
+
+// Resume re-drives a saga a previous crash left mid-flight, continuing forward from the
+// first step whose persisted status is still "pending" (i.e. the process crashed before ever
+// attempting it — e.g. between reserve_inventory completing and charge_payment starting).
+// It reports false with no error if sagaID isn't in the store, every step already completed,
+// or a step already reached a terminal failure (already "failed", "compensating", or
+// "compensated") — that case needs a human decision via GET /order/:id/saga, not an automatic
+// retry of a saga we already gave up on and may have partially compensated.
+func (s *Saga) Resume(sagaID string, steps []Step) (bool, error) {
+	state, exists, err := s.store.Load(sagaID)
+	if err != nil {
+		return false, fmt.Errorf("saga: failed to load state for resume: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	resumeAt := -1
+	for i, record := range state.Steps {
+		if record.Status == StepPending {
+			resumeAt = i
+			break
+		}
+		if record.Status != StepCompleted {
+			return false, nil
+		}
+	}
+	if resumeAt == -1 {
+		return false, nil
+	}
+	if resumeAt >= len(steps) {
+		return false, fmt.Errorf("saga: persisted state for %q has more steps than the current definition", sagaID)
+	}
+
+	return true, s.run(state, steps, resumeAt)
+}
+
+// run executes steps[startIndex:] against the already-persisted state, then compensates
+// backwards from the failing step on error. Both Run (startIndex 0, freshly initialized
+// state) and Resume (startIndex at the first pending step, state loaded from the store)
+// share this core.
+func (s *Saga) run(state *SagaState, steps []Step, startIndex int) error {
+	failedAt := -1
+	var stepErr error
+
+	for i := startIndex; i < len(steps); i++ {
+		step := steps[i]
+		start := time.Now()
+		err := step.Action()
+		metrics.SagaDuration.WithLabelValues(s.service, step.Name).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			state.Steps[i].Status = StepFailed
+			state.Steps[i].Error = err.Error()
+			metrics.SagaStepTotal.WithLabelValues(s.service, step.Name, "failed").Inc()
+			_ = s.store.Save(state)
+			failedAt = i
+			stepErr = err
+			break
+		}
+
+		state.Steps[i].Status = StepCompleted
+		metrics.SagaStepTotal.WithLabelValues(s.service, step.Name, "completed").Inc()
+		if err := s.store.Save(state); err != nil {
+			return fmt.Errorf("saga: failed to persist step %q: %w", step.Name, err)
+		}
+	}
+
+	if stepErr == nil {
+		return nil
+	}
+
+	for i := failedAt - 1; i >= 0; i-- {
+		step := steps[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		state.Steps[i].Status = StepCompensating
+		_ = s.store.Save(state)
+
+		if err := step.Compensate(); err != nil {
+			state.Steps[i].Status = StepFailed
+			state.Steps[i].Error = fmt.Sprintf("compensation failed: %v", err)
+			metrics.SagaStepTotal.WithLabelValues(s.service, step.Name, "compensation_failed").Inc()
+		} else {
+			state.Steps[i].Status = StepCompensated
+			metrics.SagaStepTotal.WithLabelValues(s.service, step.Name, "compensated").Inc()
+		}
+		_ = s.store.Save(state)
+	}
+
+	return stepErr
+}
@@ -0,0 +1,174 @@
+package patterns
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSagaRunCompletesAllSteps(t *testing.T) {
+	store := NewInMemorySagaStore()
+	saga := NewSaga(store, "test-service")
+
+	var ran []string
+	steps := []Step{
+		{Name: "a", Action: func() error { ran = append(ran, "a"); return nil }},
+		{Name: "b", Action: func() error { ran = append(ran, "b"); return nil }},
+	}
+
+	if err := saga.Run("order-1", steps, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(ran) != 2 || ran[0] != "a" || ran[1] != "b" {
+		t.Fatalf("got steps run %v, want [a b]", ran)
+	}
+
+	state, exists, err := store.Load("order-1")
+	if err != nil || !exists {
+		t.Fatalf("Load: exists=%v err=%v", exists, err)
+	}
+	for _, record := range state.Steps {
+		if record.Status != StepCompleted {
+			t.Errorf("step %q: got status %q, want %q", record.Name, record.Status, StepCompleted)
+		}
+	}
+}
+
+func TestSagaRunCompensatesPreviousStepsOnFailure(t *testing.T) {
+	store := NewInMemorySagaStore()
+	saga := NewSaga(store, "test-service")
+
+	var compensated []string
+	stepErr := errors.New("charge_payment failed")
+	steps := []Step{
+		{
+			Name:       "reserve_inventory",
+			Action:     func() error { return nil },
+			Compensate: func() error { compensated = append(compensated, "reserve_inventory"); return nil },
+		},
+		{
+			Name:   "charge_payment",
+			Action: func() error { return stepErr },
+		},
+	}
+
+	err := saga.Run("order-2", steps, nil)
+	if !errors.Is(err, stepErr) {
+		t.Fatalf("got error %v, want %v", err, stepErr)
+	}
+	if len(compensated) != 1 || compensated[0] != "reserve_inventory" {
+		t.Fatalf("got compensated steps %v, want [reserve_inventory]", compensated)
+	}
+
+	state, _, _ := store.Load("order-2")
+	if state.Steps[0].Status != StepCompensated {
+		t.Errorf("reserve_inventory: got status %q, want %q", state.Steps[0].Status, StepCompensated)
+	}
+	if state.Steps[1].Status != StepFailed {
+		t.Errorf("charge_payment: got status %q, want %q", state.Steps[1].Status, StepFailed)
+	}
+}
+
+func TestSagaRunPersistsPayloadForResume(t *testing.T) {
+	store := NewInMemorySagaStore()
+	saga := NewSaga(store, "test-service")
+
+	type order struct {
+		ID string
+	}
+	if err := saga.Run("order-3", []Step{{Name: "a", Action: func() error { return nil }}}, order{ID: "order-3"}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	state, _, _ := store.Load("order-3")
+	if len(state.Payload) == 0 {
+		t.Fatal("got empty Payload, want the marshaled order to be persisted")
+	}
+}
+
+// TestSagaResumeContinuesFromFirstPendingStep pins the resume invariant: a saga that crashed
+// after completing an earlier step but before the next one ever ran picks up at that step
+// rather than re-running (and double-applying) the completed one.
+func TestSagaResumeContinuesFromFirstPendingStep(t *testing.T) {
+	store := NewInMemorySagaStore()
+	// Simulate a crash right after reserve_inventory completed but before charge_payment ran.
+	store.Save(&SagaState{
+		SagaID: "order-4",
+		Steps: []StepRecord{
+			{Name: "reserve_inventory", Status: StepCompleted},
+			{Name: "charge_payment", Status: StepPending},
+		},
+	})
+
+	saga := NewSaga(store, "test-service")
+	var ran []string
+	steps := []Step{
+		{Name: "reserve_inventory", Action: func() error { ran = append(ran, "reserve_inventory"); return nil }},
+		{Name: "charge_payment", Action: func() error { ran = append(ran, "charge_payment"); return nil }},
+	}
+
+	resumed, err := saga.Resume("order-4", steps)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if !resumed {
+		t.Fatal("got resumed=false, want true")
+	}
+	if len(ran) != 1 || ran[0] != "charge_payment" {
+		t.Fatalf("got steps run %v, want [charge_payment] (reserve_inventory must not re-run)", ran)
+	}
+}
+
+func TestSagaResumeIsNoopWhenSagaNotFound(t *testing.T) {
+	saga := NewSaga(NewInMemorySagaStore(), "test-service")
+	resumed, err := saga.Resume("missing", []Step{{Name: "a", Action: func() error { return nil }}})
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed {
+		t.Fatal("got resumed=true for a saga ID never saved, want false")
+	}
+}
+
+func TestSagaResumeIsNoopWhenAllStepsCompleted(t *testing.T) {
+	store := NewInMemorySagaStore()
+	store.Save(&SagaState{
+		SagaID: "order-5",
+		Steps:  []StepRecord{{Name: "a", Status: StepCompleted}},
+	})
+
+	saga := NewSaga(store, "test-service")
+	ranAgain := false
+	resumed, err := saga.Resume("order-5", []Step{{Name: "a", Action: func() error { ranAgain = true; return nil }}})
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed || ranAgain {
+		t.Fatalf("got resumed=%v ranAgain=%v for a fully-completed saga, want false/false", resumed, ranAgain)
+	}
+}
+
+// TestSagaResumeLeavesFailedSagaAlone pins the "needs a human" escape hatch: a saga already
+// in a terminal failure/compensation state must not be silently auto-retried by Resume.
+func TestSagaResumeLeavesFailedSagaAlone(t *testing.T) {
+	store := NewInMemorySagaStore()
+	store.Save(&SagaState{
+		SagaID: "order-6",
+		Steps: []StepRecord{
+			{Name: "a", Status: StepCompensated},
+			{Name: "b", Status: StepFailed},
+		},
+	})
+
+	saga := NewSaga(store, "test-service")
+	ranAgain := false
+	resumed, err := saga.Resume("order-6", []Step{
+		{Name: "a", Action: func() error { return nil }},
+		{Name: "b", Action: func() error { ranAgain = true; return nil }},
+	})
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed || ranAgain {
+		t.Fatalf("got resumed=%v ranAgain=%v for an already-failed saga, want false/false", resumed, ranAgain)
+	}
+}
@@ -0,0 +1,147 @@
+// Package tracing initializes OpenTelemetry tracing so a request can be followed across
+// order-service -> inventory-service/payment-service as a single trace, and pairs that with
+// an X-Request-ID correlation header for stitching together the plain JSON logs each service
+// already emits.
+package tracing
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Init configures the global TracerProvider for serviceName, exporting spans via OTLP/gRPC.
+// The exporter's endpoint, headers, and TLS settings come from the standard
+// OTEL_EXPORTER_OTLP_* environment variables, so no code change is needed to point a
+// deployment at a different collector. Call the returned shutdown func on process exit to
+// flush pending spans.
+func Init(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the named tracer a service should use to start its own spans (e.g. around
+// bulkhead/circuit-breaker execution), on top of the server spans Middleware creates.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// Middleware extracts an incoming traceparent header, if present, and starts a server span
+// for the request named "METHOD /route", so an outbound call from order-service shows up as
+// a child of the same trace in the downstream service.
+func Middleware(serviceName string) gin.HandlerFunc {
+	tracer := Tracer(serviceName)
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+const requestIDKey = "request_id"
+
+type requestIDContextKey struct{}
+
+// RequestID is Gin middleware that assigns every request a correlation ID: it reuses an
+// inbound X-Request-ID header if the caller already set one (so a retried or chained call
+// keeps the same ID), generates one otherwise, stashes it on both c and the request's
+// context.Context (for RequestIDFrom/RequestIDFromContext respectively), and echoes it back
+// on the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), requestID))
+		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Next()
+	}
+}
+
+// RequestIDFrom returns the correlation ID RequestID stashed on c, or "" if the middleware
+// wasn't registered.
+func RequestIDFrom(c *gin.Context) string {
+	if v, ok := c.Get(requestIDKey); ok {
+		if id, ok := v.(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// ContextWithRequestID returns a copy of ctx carrying requestID, for code that only has a
+// context.Context (e.g. a resty request built outside a gin.Context) rather than the gin.Context
+// RequestIDFrom expects.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the correlation ID ContextWithRequestID stashed on ctx, or ""
+// if it wasn't set.
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDContextKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// InstrumentRestyClient wraps client's transport with OpenTelemetry's HTTP instrumentation,
+// so every outgoing request injects the active span's traceparent header and is itself
+// recorded as a child span of whatever span is on the request's context.
+func InstrumentRestyClient(client *resty.Client) *resty.Client {
+	return client.SetTransport(otelhttp.NewTransport(client.GetClient().Transport))
+}
+
+// PropagateRequestID makes client forward the caller's X-Request-ID on every outgoing
+// request, reading it from the request's context (as stashed by RequestID via
+// ContextWithRequestID). Without this, each service's own RequestID middleware mints a fresh
+// ID for every call, and logs across services can only be stitched by OTel trace_id, not the
+// simpler request_id field.
+func PropagateRequestID(client *resty.Client) *resty.Client {
+	return client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		if requestID := RequestIDFromContext(req.Context()); requestID != "" {
+			req.SetHeader("X-Request-ID", requestID)
+		}
+		return nil
+	})
+}
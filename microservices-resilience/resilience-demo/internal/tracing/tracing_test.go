@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+)
+
+func newOKServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestRequestIDContextRoundTrip(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "req-123")
+
+	if got := RequestIDFromContext(ctx); got != "req-123" {
+		t.Fatalf("got %q, want %q", got, "req-123")
+	}
+}
+
+func TestRequestIDFromContextUnset(t *testing.T) {
+	if got := RequestIDFromContext(context.Background()); got != "" {
+		t.Fatalf("got %q, want empty string", got)
+	}
+}
+
+// TestRequestIDStashesIntoRequestContext pins the fix this test accompanies: RequestID
+// must stash the ID into c.Request's context.Context, not just onto the gin.Context, so
+// it survives into an outbound resty call built from that context.
+func TestRequestIDStashesIntoRequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+
+	var gotFromContext, gotFromGin string
+	router.GET("/", func(c *gin.Context) {
+		gotFromContext = RequestIDFromContext(c.Request.Context())
+		gotFromGin = RequestIDFrom(c)
+		c.Status(200)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "inbound-id")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if gotFromContext != "inbound-id" {
+		t.Errorf("got RequestIDFromContext %q, want %q", gotFromContext, "inbound-id")
+	}
+	if gotFromGin != "inbound-id" {
+		t.Errorf("got RequestIDFrom %q, want %q", gotFromGin, "inbound-id")
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "inbound-id" {
+		t.Errorf("got response header %q, want %q", got, "inbound-id")
+	}
+}
+
+// TestPropagateRequestIDForwardsHeader pins PropagateRequestID's purpose: a client wrapped
+// with it must forward whatever request ID is on the outgoing request's context as an
+// X-Request-ID header, so downstream logs can be stitched to the originating request.
+func TestPropagateRequestIDForwardsHeader(t *testing.T) {
+	var gotHeader string
+	server := newOKServer()
+	defer server.Close()
+
+	client := PropagateRequestID(resty.New())
+	client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		gotHeader = req.Header.Get("X-Request-ID")
+		return nil
+	})
+
+	ctx := ContextWithRequestID(context.Background(), "downstream-id")
+	_, _ = client.R().SetContext(ctx).Get(server.URL)
+
+	if gotHeader != "downstream-id" {
+		t.Fatalf("got X-Request-ID %q, want %q", gotHeader, "downstream-id")
+	}
+}
+
+func TestPropagateRequestIDNoopWithoutContextValue(t *testing.T) {
+	server := newOKServer()
+	defer server.Close()
+
+	client := PropagateRequestID(resty.New())
+	resp, err := client.R().Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resp.Request.Header.Get("X-Request-ID"); got != "" {
+		t.Fatalf("got X-Request-ID %q, want empty", got)
+	}
+}
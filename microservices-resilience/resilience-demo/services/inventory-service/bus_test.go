@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ashendes/resilience-demo/internal/bus"
+	"github.com/ashendes/resilience-demo/internal/models"
+	"github.com/ashendes/resilience-demo/internal/patterns"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TestMain wires up the pieces main() normally sets up before starting the router --
+// the message bus, reservation bulkhead, and waiter registry -- so publishOrderCreated
+// has something to publish onto.
+func TestMain(m *testing.M) {
+	busDir, err := os.MkdirTemp("", "inventory-bus-test")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(busDir)
+
+	messageBus, err := bus.NewWALBus(busDir)
+	if err != nil {
+		panic(err)
+	}
+	inventoryService.messageBus = messageBus
+	inventoryService.reservationBulkhead = patterns.NewBulkhead(10, "saga-reserve", "inventory-service")
+	inventoryService.reservationWaiters = newReservationWaiters()
+
+	if err := messageBus.Subscribe("order.created", handleOrderCreated); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newBusTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/bus/order-created", publishOrderCreated)
+	return router
+}
+
+func publishOrderCreatedRequest(t *testing.T, router *gin.Engine, event models.OrderCreatedEvent) (int, map[string]interface{}) {
+	t.Helper()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bus/order-created", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+// TestPublishOrderCreatedReservesStockViaBus pins the bridge publishOrderCreated exists for:
+// a synchronous HTTP caller (order-service) publishes order.created onto the durable bus and
+// gets back the same synchronous answer it would from a direct reservation, once
+// handleOrderCreated runs asynchronously off the bus dispatch.
+func TestPublishOrderCreatedReservesStockViaBus(t *testing.T) {
+	router := newBusTestRouter()
+
+	inventoryService.mutex.Lock()
+	before := inventoryService.items["item-2"].Quantity
+	inventoryService.mutex.Unlock()
+
+	orderID := uuid.New().String()
+	code, resp := publishOrderCreatedRequest(t, router, models.OrderCreatedEvent{
+		OrderID: orderID,
+		Items:   []models.OrderItem{{ItemID: "item-2", Quantity: 3, Price: 29.99}},
+	})
+
+	if code != http.StatusOK {
+		t.Fatalf("got status %d, want %d (body: %v)", code, http.StatusOK, resp)
+	}
+	if resp["success"] != true {
+		t.Fatalf("got success=%v, want true", resp["success"])
+	}
+
+	inventoryService.mutex.Lock()
+	after := inventoryService.items["item-2"].Quantity
+	inventoryService.mutex.Unlock()
+
+	if after != before-3 {
+		t.Fatalf("got quantity %d after reservation, want %d", after, before-3)
+	}
+}
+
+func TestPublishOrderCreatedReportsInsufficientStockAsConflict(t *testing.T) {
+	router := newBusTestRouter()
+
+	orderID := uuid.New().String()
+	code, resp := publishOrderCreatedRequest(t, router, models.OrderCreatedEvent{
+		OrderID: orderID,
+		Items:   []models.OrderItem{{ItemID: "item-5", Quantity: 1_000_000, Price: 149.99}},
+	})
+
+	if code != http.StatusConflict {
+		t.Fatalf("got status %d, want %d (body: %v)", code, http.StatusConflict, resp)
+	}
+	if resp["success"] != false {
+		t.Fatalf("got success=%v, want false", resp["success"])
+	}
+}
+
+func TestPublishOrderCreatedUnknownItemReturnsConflict(t *testing.T) {
+	router := newBusTestRouter()
+
+	orderID := uuid.New().String()
+	code, resp := publishOrderCreatedRequest(t, router, models.OrderCreatedEvent{
+		OrderID: orderID,
+		Items:   []models.OrderItem{{ItemID: "does-not-exist", Quantity: 1, Price: 1}},
+	})
+
+	if code != http.StatusConflict {
+		t.Fatalf("got status %d, want %d (body: %v)", code, http.StatusConflict, resp)
+	}
+}
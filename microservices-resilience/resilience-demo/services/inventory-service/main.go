@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ashendes/resilience-demo/internal/bus"
+	"github.com/ashendes/resilience-demo/internal/chaos"
+	"github.com/ashendes/resilience-demo/internal/metrics"
+	"github.com/ashendes/resilience-demo/internal/models"
+	"github.com/ashendes/resilience-demo/internal/patterns"
+	"github.com/ashendes/resilience-demo/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+// InventoryService manages inventory operations
+type InventoryService struct {
+	items map[string]*models.Item
+	mutex sync.RWMutex
+
+	reserveLimiter *patterns.RateLimiter
+	checkLimiter   *patterns.RateLimiter
+
+	messageBus          *bus.WALBus
+	reservationBulkhead *patterns.Bulkhead
+	reservationWaiters  *reservationWaiters
+}
+
+// reservationOutcome is the result of a saga reservation attempt, delivered to whichever
+// /bus/order-created request is waiting on it.
+type reservationOutcome struct {
+	reserved bool
+	reason   string
+}
+
+// reservationWaiters correlates a synchronous POST /bus/order-created request with the
+// asynchronous outcome handleOrderCreated eventually produces, by order ID. The bus itself
+// has no concept of a request/response round trip -- this is the seam that lets
+// order-service treat publishing order.created as a normal, synchronous saga step.
+type reservationWaiters struct {
+	mutex   sync.Mutex
+	byOrder map[string]chan reservationOutcome
+}
+
+func newReservationWaiters() *reservationWaiters {
+	return &reservationWaiters{byOrder: make(map[string]chan reservationOutcome)}
+}
+
+func (rw *reservationWaiters) register(orderID string) chan reservationOutcome {
+	ch := make(chan reservationOutcome, 1)
+	rw.mutex.Lock()
+	rw.byOrder[orderID] = ch
+	rw.mutex.Unlock()
+	return ch
+}
+
+func (rw *reservationWaiters) forget(orderID string) {
+	rw.mutex.Lock()
+	delete(rw.byOrder, orderID)
+	rw.mutex.Unlock()
+}
+
+// notify delivers outcome to the waiter registered for orderID, if any. There is none when
+// handleOrderCreated runs from Replay (the original HTTP caller is long gone by then), in
+// which case notify is a no-op.
+func (rw *reservationWaiters) notify(orderID string, outcome reservationOutcome) {
+	rw.mutex.Lock()
+	ch, ok := rw.byOrder[orderID]
+	rw.mutex.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- outcome:
+	default:
+	}
+}
+
+var inventoryChaos *chaos.FaultInjector
+
+// rateLimitKeyContextKey is the context key under which the caller identity used for rate
+// limiting (order ID, API key, or client IP) is stashed.
+type rateLimitKeyContextKey struct{}
+
+// rateLimitKeyFunc resolves the identity tracked by the inventory service's rate limiters.
+func rateLimitKeyFunc(ctx context.Context) string {
+	if key, ok := ctx.Value(rateLimitKeyContextKey{}).(string); ok && key != "" {
+		return key
+	}
+	return "anonymous"
+}
+
+// withRateLimitIdentity is Gin middleware that resolves the rate-limiting identity for a
+// request (API key header if present, else the client IP) into the request context.
+func withRateLimitIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := c.GetHeader("X-API-Key")
+		if identity == "" {
+			identity = c.ClientIP()
+		}
+		ctx := context.WithValue(c.Request.Context(), rateLimitKeyContextKey{}, identity)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+var inventoryService *InventoryService
+
+func init() {
+	// Initialize logger
+	log.SetFormatter(&log.JSONFormatter{})
+	log.SetLevel(log.InfoLevel)
+
+	// Seed random number generator
+	rand.Seed(time.Now().UnixNano())
+
+	inventoryChaos = chaos.NewFaultInjector("inventory-service")
+
+	// Initialize inventory with sample data
+	inventoryService = &InventoryService{
+		items: make(map[string]*models.Item),
+		reserveLimiter: patterns.NewRateLimiter("Reserve", "inventory-service", patterns.RateLimiterConfig{
+			Algorithm: patterns.TokenBucket,
+			Rate:      20,
+			Burst:     40,
+			KeyFunc:   rateLimitKeyFunc,
+		}),
+		checkLimiter: patterns.NewRateLimiter("Check", "inventory-service", patterns.RateLimiterConfig{
+			Algorithm: patterns.TokenBucket,
+			Rate:      50,
+			Burst:     100,
+			KeyFunc:   rateLimitKeyFunc,
+		}),
+	}
+
+	// Add sample items
+	sampleItems := []*models.Item{
+		{ID: "item-1", Name: "Laptop", Quantity: 10000, Price: 999.99},
+		{ID: "item-2", Name: "Mouse", Quantity: 50000, Price: 29.99},
+		{ID: "item-3", Name: "Keyboard", Quantity: 30000, Price: 79.99},
+		{ID: "item-4", Name: "Monitor", Quantity: 15000, Price: 299.99},
+		{ID: "item-5", Name: "Headphones", Quantity: 2000, Price: 149.99},
+	}
+
+	for _, item := range sampleItems {
+		inventoryService.items[item.ID] = item
+		// Initialize inventory level metric
+		metrics.InventoryLevel.WithLabelValues(item.ID).Set(float64(item.Quantity))
+	}
+}
+
+func main() {
+	shutdownTracing, err := tracing.Init(context.Background(), "inventory-service")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing: ", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.WithError(err).Warn("Failed to flush tracer provider on shutdown")
+		}
+	}()
+
+	// Initialize the durable saga bus: handleOrderCreated reserves stock for an
+	// order.created event inside a bulkhead and emits the compensating
+	// inventory.reserved/inventory.reservation_failed event. The bus itself has no
+	// cross-process transport, so order-service reaches it through the
+	// POST /bus/order-created HTTP bridge (see publishOrderCreated) rather than
+	// publishing directly -- everything past that bridge (WAL durability, replay,
+	// idempotent redelivery) is the same saga path this package was built for.
+	busDir := getEnv("INVENTORY_BUS_DIR", "./data/inventory-bus")
+	messageBus, err := bus.NewWALBus(busDir)
+	if err != nil {
+		log.Fatal("Failed to initialize message bus: ", err)
+	}
+	inventoryService.messageBus = messageBus
+	inventoryService.reservationBulkhead = patterns.NewBulkhead(10, "saga-reserve", "inventory-service")
+	inventoryService.reservationWaiters = newReservationWaiters()
+
+	if err := messageBus.Subscribe("order.created", handleOrderCreated); err != nil {
+		log.Fatal("Failed to subscribe to order.created: ", err)
+	}
+	if err := messageBus.Replay(); err != nil {
+		log.Fatal("Failed to replay message bus WAL: ", err)
+	}
+
+	router := gin.Default()
+
+	// Add Prometheus middleware
+	router.Use(metrics.PrometheusMiddleware("inventory-service"))
+	router.Use(tracing.RequestID())
+	router.Use(tracing.Middleware("inventory-service"))
+	router.Use(withRateLimitIdentity())
+	// Fault injection applies uniformly to every endpoint instead of being sprinkled
+	// inside handlers.
+	router.Use(inventoryChaos.Middleware())
+
+	// Health check endpoints
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/inventory/status", getStatus)
+
+	// Inventory endpoints. order-service reserves/releases stock through the saga-bus
+	// path below; these synchronous endpoints remain as its fallback for when that
+	// path itself is unavailable (e.g. the saga-bus circuit breaker is open).
+	router.GET("/inventory/check/:itemId", checkInventory)
+	router.POST("/inventory/reserve", reserveItems)
+	router.POST("/inventory/release", releaseItems)
+
+	// Saga bus: order-service's primary path for reserving inventory. Publishing
+	// order.created here gets the full saga treatment (bulkhead, WAL durability,
+	// idempotent redelivery on replay) before the caller gets its synchronous answer.
+	router.POST("/bus/order-created", publishOrderCreated)
+	router.GET("/bus/stats", busStats)
+
+	// Chaos engineering endpoints
+	router.GET("/chaos/config", getChaosConfig)
+	router.POST("/chaos/config", setChaosConfig)
+	router.GET("/chaos/scenario", getChaosScenario)
+	router.POST("/chaos/scenario", setChaosScenario)
+	router.DELETE("/chaos/config", clearChaosConfig)
+
+	// Metrics endpoint
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	log.Info("Inventory Service starting on port 8081")
+	if err := router.Run(":8081"); err != nil {
+		log.Fatal("Failed to start server: ", err)
+	}
+}
+
+// getEnv gets environment variable with fallback
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
+
+// busStats exposes per-topic head/consumer offsets so Prometheus can alert on a stalled
+// saga consumer.
+func busStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"topics": inventoryService.messageBus.Stats()})
+}
+
+// handleOrderCreated is the inventory side of the reservation saga: it reserves stock for
+// an order.created event inside a bulkhead and emits the compensating event the order
+// service listens for.
+func handleOrderCreated(msg bus.Message) error {
+	start := time.Now()
+
+	var event models.OrderCreatedEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		metrics.SagaStepTotal.WithLabelValues("inventory-service", "reserve_inventory", "failed").Inc()
+		return fmt.Errorf("saga: failed to decode order.created event: %w", err)
+	}
+
+	reserveErr := inventoryService.reservationBulkhead.Execute(func() error {
+		return inventoryService.reserveOrderItems(event.OrderID, event.Items)
+	})
+
+	metrics.SagaDuration.WithLabelValues("inventory-service", "reserve_inventory").Observe(time.Since(start).Seconds())
+
+	if reserveErr != nil {
+		metrics.SagaStepTotal.WithLabelValues("inventory-service", "reserve_inventory", "failed").Inc()
+		if err := publishInventoryEvent("inventory.reservation_failed", models.InventoryReservationFailedEvent{
+			OrderID: event.OrderID,
+			Reason:  reserveErr.Error(),
+		}); err != nil {
+			log.WithError(err).Error("saga: failed to publish inventory.reservation_failed")
+		}
+		inventoryService.reservationWaiters.notify(event.OrderID, reservationOutcome{reason: reserveErr.Error()})
+		// The failure is terminal and already communicated via the event, so ack the
+		// message rather than leaving it to be redelivered by Replay.
+		return nil
+	}
+
+	metrics.SagaStepTotal.WithLabelValues("inventory-service", "reserve_inventory", "completed").Inc()
+	if err := publishInventoryEvent("inventory.reserved", models.InventoryReservedEvent{OrderID: event.OrderID}); err != nil {
+		log.WithError(err).Error("saga: failed to publish inventory.reserved")
+	}
+	inventoryService.reservationWaiters.notify(event.OrderID, reservationOutcome{reserved: true})
+	return nil
+}
+
+// publishOrderCreated is order-service's primary entry point for reserving inventory: it
+// publishes order.created onto the durable bus -- giving the reservation the same WAL
+// durability and idempotent-redelivery guarantees as a crash-and-replay scenario -- and
+// blocks until handleOrderCreated (run asynchronously off the bus dispatch) produces an
+// outcome, so the HTTP caller's saga step still gets a synchronous answer.
+func publishOrderCreated(c *gin.Context) {
+	var event models.OrderCreatedEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	waiter := inventoryService.reservationWaiters.register(event.OrderID)
+	defer inventoryService.reservationWaiters.forget(event.OrderID)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to encode order.created event: " + err.Error(),
+		})
+		return
+	}
+
+	if err := inventoryService.messageBus.Publish("order.created", bus.Message{
+		ID:        uuid.New().String(),
+		Topic:     "order.created",
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to publish order.created: " + err.Error(),
+		})
+		return
+	}
+
+	select {
+	case outcome := <-waiter:
+		if !outcome.reserved {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": outcome.reason,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, models.ReserveItemsResponse{
+			Success: true,
+			Message: "Items reserved successfully via saga",
+		})
+
+	case <-c.Request.Context().Done():
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"success": false,
+			"message": "Timed out waiting for saga reservation outcome",
+		})
+	}
+}
+
+func publishInventoryEvent(topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return inventoryService.messageBus.Publish(topic, bus.Message{
+		ID:        uuid.New().String(),
+		Topic:     topic,
+		Payload:   data,
+		Timestamp: time.Now(),
+	})
+}
+
+// reserveOrderItems decrements stock for a saga step. It requires handlers upstream to be
+// idempotent on order_id (see bus.WALBus.Replay), since a crash between receive and ack can
+// redeliver the same order.created event.
+func (is *InventoryService) reserveOrderItems(orderID string, items []models.OrderItem) error {
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+
+	for _, orderItem := range items {
+		item, exists := is.items[orderItem.ItemID]
+		if !exists {
+			return fmt.Errorf("item not found: %s", orderItem.ItemID)
+		}
+		if item.Quantity < orderItem.Quantity {
+			return fmt.Errorf("insufficient inventory for item: %s", orderItem.ItemID)
+		}
+	}
+
+	for _, orderItem := range items {
+		item := is.items[orderItem.ItemID]
+		item.Quantity -= orderItem.Quantity
+		metrics.InventoryLevel.WithLabelValues(item.ID).Set(float64(item.Quantity))
+	}
+
+	log.WithFields(log.Fields{
+		"order_id": orderID,
+		"items":    len(items),
+	}).Info("Items reserved successfully via saga")
+
+	return nil
+}
+
+func getStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"service":   "inventory-service",
+		"status":    "healthy",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func getChaosConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, inventoryChaos.Config())
+}
+
+func setChaosConfig(c *gin.Context) {
+	var cfg chaos.Config
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid chaos config: " + err.Error()})
+		return
+	}
+
+	inventoryChaos.SetConfig(cfg)
+	log.WithField("rules", len(cfg.Rules)).Info("Chaos config updated for inventory service")
+	c.JSON(http.StatusOK, inventoryChaos.Config())
+}
+
+func getChaosScenario(c *gin.Context) {
+	c.JSON(http.StatusOK, inventoryChaos.Scenario())
+}
+
+func setChaosScenario(c *gin.Context) {
+	var scenario chaos.Scenario
+	if err := c.ShouldBindJSON(&scenario); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid chaos scenario: " + err.Error()})
+		return
+	}
+
+	inventoryChaos.SetScenario(scenario)
+	log.WithFields(log.Fields{
+		"rules":            len(scenario.Rules),
+		"duration_seconds": scenario.DurationSeconds,
+		"seeded":           scenario.Seed != nil,
+		"request_id":       tracing.RequestIDFrom(c),
+	}).Info("Chaos scenario activated for inventory service")
+	c.JSON(http.StatusOK, inventoryChaos.Scenario())
+}
+
+func clearChaosConfig(c *gin.Context) {
+	inventoryChaos.Clear()
+	log.Info("Chaos config cleared for inventory service")
+	c.JSON(http.StatusOK, gin.H{"message": "Chaos config cleared"})
+}
+
+func checkInventory(c *gin.Context) {
+	itemID := c.Param("itemId")
+
+	if _, err := inventoryService.checkLimiter.Execute(c.Request.Context(), func() (interface{}, error) {
+		return nil, nil
+	}); err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"available": false,
+			"quantity":  0,
+			"message":   "Rate limit exceeded, try again shortly",
+		})
+		return
+	}
+
+	inventoryService.mutex.RLock()
+	item, exists := inventoryService.items[itemID]
+	inventoryService.mutex.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"available": false,
+			"quantity":  0,
+			"message":   "Item not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CheckInventoryResponse{
+		Available: item.Quantity > 0,
+		Quantity:  item.Quantity,
+	})
+}
+
+func reserveItems(c *gin.Context) {
+	var req models.ReserveItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := inventoryService.reserveLimiter.Execute(c.Request.Context(), func() (interface{}, error) {
+		return nil, nil
+	}); err != nil {
+		log.WithField("order_id", req.OrderID).Warn("Reservation rejected: rate limit exceeded")
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"message": "Rate limit exceeded, try again shortly",
+		})
+		return
+	}
+
+	inventoryService.mutex.Lock()
+	defer inventoryService.mutex.Unlock()
+
+	// Check if all items are available
+	for _, orderItem := range req.Items {
+		item, exists := inventoryService.items[orderItem.ItemID]
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Item not found: " + orderItem.ItemID,
+			})
+			return
+		}
+
+		if item.Quantity < orderItem.Quantity {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "Insufficient inventory for item: " + orderItem.ItemID,
+			})
+			return
+		}
+	}
+
+	// Reserve items (deduct from inventory)
+	for _, orderItem := range req.Items {
+		item := inventoryService.items[orderItem.ItemID]
+		item.Quantity -= orderItem.Quantity
+		// Update metric
+		metrics.InventoryLevel.WithLabelValues(item.ID).Set(float64(item.Quantity))
+	}
+
+	log.WithFields(log.Fields{
+		"order_id":   req.OrderID,
+		"items":      len(req.Items),
+		"request_id": tracing.RequestIDFrom(c),
+	}).Info("Items reserved successfully")
+
+	c.JSON(http.StatusOK, models.ReserveItemsResponse{
+		Success: true,
+		Message: "Items reserved successfully",
+	})
+}
+
+func releaseItems(c *gin.Context) {
+	var req models.ReleaseItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	inventoryService.mutex.Lock()
+	defer inventoryService.mutex.Unlock()
+
+	// Release items (add back to inventory)
+	for _, orderItem := range req.Items {
+		item, exists := inventoryService.items[orderItem.ItemID]
+		if exists {
+			item.Quantity += orderItem.Quantity
+			// Update metric
+			metrics.InventoryLevel.WithLabelValues(item.ID).Set(float64(item.Quantity))
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"order_id":   req.OrderID,
+		"items":      len(req.Items),
+		"request_id": tracing.RequestIDFrom(c),
+	}).Info("Items released successfully")
+
+	c.JSON(http.StatusOK, models.ReleaseItemsResponse{
+		Success: true,
+		Message: "Items released successfully",
+	})
+}
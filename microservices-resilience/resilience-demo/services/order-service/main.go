@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,11 +12,13 @@ import (
 	"github.com/ashendes/resilience-demo/internal/metrics"
 	"github.com/ashendes/resilience-demo/internal/models"
 	"github.com/ashendes/resilience-demo/internal/patterns"
+	"github.com/ashendes/resilience-demo/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // OrderService manages order operations
@@ -28,8 +31,11 @@ type OrderService struct {
 	paymentCircuit      *patterns.CircuitBreakerWrapper
 	inventoryBulkhead   *patterns.Bulkhead
 	paymentBulkhead     *patterns.Bulkhead
+	inventoryRetrier    *patterns.Retrier
+	paymentRetrier      *patterns.Retrier
 	inventoryServiceURL string
 	paymentServiceURL   string
+	saga                *patterns.Saga
 }
 
 var orderService *OrderService
@@ -41,31 +47,63 @@ func init() {
 }
 
 func main() {
+	shutdownTracing, err := tracing.Init(context.Background(), "order-service")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing: ", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.WithError(err).Warn("Failed to flush tracer provider on shutdown")
+		}
+	}()
+
 	// Get service URLs from environment or use defaults
 	inventoryServiceURL := getEnv("INVENTORY_SERVICE_URL", "http://localhost:8081")
 	paymentServiceURL := getEnv("PAYMENT_SERVICE_URL", "http://localhost:8082")
 
+	// The saga store is file-backed so a crash between reserveInventory and processPayment
+	// leaves a record to resume on the next startup, rather than silently orphaning stock.
+	sagaDir := getEnv("ORDER_SAGA_DIR", "./data/order-sagas")
+	sagaStore, err := patterns.NewFileSagaStore(sagaDir)
+	if err != nil {
+		log.Fatal("Failed to initialize saga store: ", err)
+	}
+
 	// Initialize order service with resilience patterns
 	orderService = &OrderService{
 		orders: make(map[string]*models.Order),
-		inventoryClient: resty.New().
+		inventoryClient: tracing.PropagateRequestID(tracing.InstrumentRestyClient(resty.New().
 			SetTimeout(patterns.DefaultTimeout).
-			SetRetryCount(0), // No automatic retries, we handle via circuit breaker
-		paymentClient: resty.New().
+			SetRetryCount(0))), // No automatic retries, we handle via circuit breaker
+		paymentClient: tracing.PropagateRequestID(tracing.InstrumentRestyClient(resty.New().
 			SetTimeout(patterns.DefaultTimeout).
-			SetRetryCount(0),
-		inventoryCircuit:    patterns.NewCircuitBreaker("Inventory", "order-service"),
-		paymentCircuit:      patterns.NewCircuitBreaker("Payment", "order-service"),
-		inventoryBulkhead:   patterns.NewBulkhead(10, "inventory", "order-service"),
-		paymentBulkhead:     patterns.NewBulkhead(10, "payment", "order-service"),
+			SetRetryCount(0))),
+		inventoryCircuit:  patterns.NewCircuitBreaker("Inventory", "order-service"),
+		paymentCircuit:    patterns.NewCircuitBreaker("Payment", "order-service"),
+		inventoryBulkhead: patterns.NewBulkhead(10, "inventory", "order-service"),
+		paymentBulkhead:   patterns.NewBulkhead(10, "payment", "order-service"),
+		inventoryRetrier: patterns.NewRetrier("Inventory", "order-service", patterns.RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     2 * time.Second,
+		}),
+		paymentRetrier: patterns.NewRetrier("Payment", "order-service", patterns.RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     2 * time.Second,
+		}),
 		inventoryServiceURL: inventoryServiceURL,
 		paymentServiceURL:   paymentServiceURL,
+		saga:                patterns.NewSaga(sagaStore, "order-service"),
 	}
+	resumeIncompleteSagas(orderService)
 
 	router := gin.Default()
 
 	// Add Prometheus middleware
 	router.Use(metrics.PrometheusMiddleware("order-service"))
+	router.Use(tracing.RequestID())
+	router.Use(tracing.Middleware("order-service"))
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -75,6 +113,7 @@ func main() {
 	// Order endpoints
 	router.POST("/order/create", createOrder)
 	router.GET("/order/:orderId", getOrder)
+	router.GET("/order/:orderId/saga", getOrderSaga)
 	router.GET("/order/circuit-status", getCircuitStatus)
 
 	// Metrics endpoint
@@ -92,6 +131,12 @@ func main() {
 
 // createOrder handles order creation with full resilience patterns
 func createOrder(c *gin.Context) {
+	ctx, span := tracing.Tracer("order-service").Start(c.Request.Context(), "createOrder")
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+
+	requestID := tracing.RequestIDFrom(c)
+
 	var req models.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		metrics.OrdersTotal.WithLabelValues("validation_failed").Inc()
@@ -120,6 +165,7 @@ func createOrder(c *gin.Context) {
 		Status:    models.OrderStatusPending,
 		Timestamp: time.Now(),
 	}
+	span.SetAttributes(attribute.String("order_id", orderID))
 
 	// Calculate total
 	totalAmount := 0.0
@@ -134,13 +180,14 @@ func createOrder(c *gin.Context) {
 	orderService.mutex.Unlock()
 
 	log.WithFields(log.Fields{
-		"order_id": orderID,
-		"items":    len(req.Items),
-		"total":    totalAmount,
+		"order_id":   orderID,
+		"request_id": requestID,
+		"items":      len(req.Items),
+		"total":      totalAmount,
 	}).Info("Processing new order")
 
 	// Process order with resilience patterns
-	if err := orderService.processOrder(order); err != nil {
+	if err := orderService.processOrder(ctx, order); err != nil {
 		order.Status = models.OrderStatusFailed
 		metrics.OrdersTotal.WithLabelValues("failed").Inc()
 
@@ -156,7 +203,7 @@ func createOrder(c *gin.Context) {
 	order.Status = models.OrderStatusCompleted
 	metrics.OrdersTotal.WithLabelValues("completed").Inc()
 
-	log.WithField("order_id", orderID).Info("Order completed successfully")
+	log.WithFields(log.Fields{"order_id": orderID, "request_id": requestID}).Info("Order completed successfully")
 
 	c.JSON(http.StatusOK, models.CreateOrderResponse{
 		OrderID: orderID,
@@ -185,6 +232,62 @@ func getOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+// getOrderSaga exposes the durable saga state for an order, so an order left mid-flight by
+// a crash (step status "compensating" or "failed") can be inspected and manually re-driven.
+func getOrderSaga(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	state, exists, err := orderService.saga.Store().Load(orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load saga state: " + err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    "Saga not found",
+			"order_id": orderID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// resumeIncompleteSagas runs once at startup, after orderService.saga is ready, to re-drive
+// any saga a previous crash left with a step it never got to attempt (e.g. a crash between
+// reserve_inventory completing and charge_payment starting, which would otherwise leave
+// reserved stock orphaned until a human intervened). A saga that already reached a terminal
+// failure (a step already "failed", "compensating", or "compensated") is left alone for
+// inspection via GET /order/:id/saga instead of being retried automatically.
+func resumeIncompleteSagas(os *OrderService) {
+	states, err := os.saga.Store().List()
+	if err != nil {
+		log.WithError(err).Warn("Failed to list saga store on startup")
+		return
+	}
+
+	for _, state := range states {
+		if len(state.Payload) == 0 {
+			continue
+		}
+
+		var order models.Order
+		if err := json.Unmarshal(state.Payload, &order); err != nil {
+			log.WithField("order_id", state.SagaID).WithError(err).Warn("Found an incomplete saga with an unreadable payload; skipping automatic resume")
+			continue
+		}
+
+		resumed, err := os.saga.Resume(state.SagaID, os.sagaSteps(context.Background(), &order))
+		if err != nil {
+			log.WithField("order_id", state.SagaID).WithError(err).Error("Failed to resume saga left incomplete by a previous run")
+			continue
+		}
+		if resumed {
+			log.WithField("order_id", state.SagaID).Info("Resumed saga left incomplete by a previous run")
+		}
+	}
+}
+
 // getCircuitStatus returns the status of circuit breakers
 func getCircuitStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -201,121 +304,238 @@ func getCircuitStatus(c *gin.Context) {
 	})
 }
 
-// processOrder orchestrates the order processing with resilience patterns
-func (os *OrderService) processOrder(order *models.Order) error {
-	// Step 1: Reserve inventory with Circuit Breaker + Bulkhead
-	if err := os.reserveInventory(order); err != nil {
-		log.WithField("order_id", order.ID).Error("Failed to reserve inventory: ", err)
-		return fmt.Errorf("inventory reservation failed: %w", err)
+// processOrder runs the order as a saga of reserve-inventory then charge-payment steps. The
+// saga engine persists each step transition, so a crash between steps leaves a record to
+// replay via GET /order/:id/saga instead of orphaning reserved stock, and compensates
+// (releases inventory) automatically if payment fails.
+func (os *OrderService) processOrder(ctx context.Context, order *models.Order) error {
+	if err := os.saga.Run(order.ID, os.sagaSteps(ctx, order), order); err != nil {
+		return fmt.Errorf("saga failed: %w", err)
 	}
+	return nil
+}
 
-	// Step 2: Process payment with Circuit Breaker + Bulkhead
-	if err := os.processPayment(order); err != nil {
-		log.WithField("order_id", order.ID).Error("Payment failed, releasing inventory: ", err)
+// sagaSteps builds the reserve-inventory-then-charge-payment step sequence for order. It is
+// shared by processOrder (a fresh saga run) and resumeIncompleteSagas (resuming one from a
+// previous crash), so both drive the exact same Actions/Compensations.
+func (os *OrderService) sagaSteps(ctx context.Context, order *models.Order) []patterns.Step {
+	return []patterns.Step{
+		{
+			Name:       "reserve_inventory",
+			Action:     func() error { return os.reserveInventory(ctx, order) },
+			Compensate: func() error { return os.releaseInventory(ctx, order) },
+		},
+		{
+			Name:       "charge_payment",
+			Action:     func() error { return os.processPayment(ctx, order) },
+			Compensate: func() error { return os.refundPayment(ctx, order) },
+		},
+	}
+}
 
-		// Rollback: Release inventory
-		if releaseErr := os.releaseInventory(order); releaseErr != nil {
-			log.WithField("order_id", order.ID).Error("Failed to release inventory during rollback: ", releaseErr)
-		}
+// reserveInventory reserves items via the inventory saga bus (see reserveInventoryViaBus),
+// falling back to the synchronous HTTP endpoint if the saga-bus path itself is unavailable
+// (e.g. a tripped circuit), so an outage of that path alone doesn't block every order.
+func (os *OrderService) reserveInventory(ctx context.Context, order *models.Order) error {
+	ctx, span := tracing.Tracer("order-service").Start(ctx, "reserve_inventory")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", order.ID))
 
-		return fmt.Errorf("payment processing failed: %w", err)
+	err := os.reserveInventoryViaBus(ctx, order)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"order_id": order.ID,
+			"error":    err.Error(),
+		}).Warn("Saga-bus reservation unavailable, falling back to synchronous HTTP reservation")
+		err = os.reserveInventoryViaHTTP(ctx, order)
 	}
 
-	return nil
+	span.SetAttributes(attribute.String("circuit_state", os.inventoryCircuit.GetState()))
+	return err
 }
 
-// reserveInventory reserves items with circuit breaker and bulkhead patterns
-func (os *OrderService) reserveInventory(order *models.Order) error {
+// reserveInventoryViaBus is the primary reservation path: it publishes order.created to
+// inventory-service's saga bus (POST /bus/order-created), retrying transient failures with
+// backoff+jitter inside the circuit breaker, itself inside the bulkhead, exactly like the
+// HTTP fallback below.
+func (os *OrderService) reserveInventoryViaBus(ctx context.Context, order *models.Order) error {
+	event := models.OrderCreatedEvent{OrderID: order.ID, Items: order.Items}
+
+	return os.inventoryBulkhead.Execute(func() error {
+		_, err := os.inventoryRetrier.Execute(ctx, func(ctx context.Context) (interface{}, error) {
+			return os.inventoryCircuit.Execute(func() (interface{}, error) {
+				resp, httpErr := os.inventoryClient.R().
+					SetContext(ctx).
+					SetHeader("Content-Type", "application/json").
+					SetBody(event).
+					Post(os.inventoryServiceURL + "/bus/order-created")
+
+				if httpErr != nil {
+					return nil, fmt.Errorf("HTTP error: %w", httpErr)
+				}
+
+				if resp.StatusCode() != http.StatusOK {
+					return nil, fmt.Errorf("inventory service returned status %d: %s", resp.StatusCode(), resp.String())
+				}
+
+				var response models.ReserveItemsResponse
+				if err := json.Unmarshal(resp.Body(), &response); err != nil {
+					return nil, fmt.Errorf("failed to parse response: %w", err)
+				}
+
+				if !response.Success {
+					return nil, fmt.Errorf("reservation failed: %s", response.Message)
+				}
+
+				return response, nil
+			})
+		})
+
+		return patterns.FormatError("Inventory", err)
+	})
+}
+
+// reserveInventoryViaHTTP is the fallback path kept for when the saga bus itself can't be
+// reached; it mutates inventory synchronously through the same resilience layering.
+func (os *OrderService) reserveInventoryViaHTTP(ctx context.Context, order *models.Order) error {
 	reserveRequest := models.ReserveItemsRequest{
 		OrderID: order.ID,
 		Items:   order.Items,
 	}
 
-	// Execute with bulkhead pattern
-	err := os.inventoryBulkhead.Execute(func() error {
-		// Execute with circuit breaker pattern
-		_, cbErr := os.inventoryCircuit.Execute(func() (interface{}, error) {
-			resp, httpErr := os.inventoryClient.R().
-				SetHeader("Content-Type", "application/json").
-				SetBody(reserveRequest).
-				Post(os.inventoryServiceURL + "/inventory/reserve")
-
-			if httpErr != nil {
-				return nil, fmt.Errorf("HTTP error: %w", httpErr)
-			}
-
-			if resp.StatusCode() != http.StatusOK {
-				return nil, fmt.Errorf("inventory service returned status %d: %s", resp.StatusCode(), resp.String())
-			}
-
-			var response models.ReserveItemsResponse
-			if err := json.Unmarshal(resp.Body(), &response); err != nil {
-				return nil, fmt.Errorf("failed to parse response: %w", err)
-			}
-
-			if !response.Success {
-				return nil, fmt.Errorf("reservation failed: %s", response.Message)
-			}
-
-			return response, nil
+	return os.inventoryBulkhead.Execute(func() error {
+		_, err := os.inventoryRetrier.Execute(ctx, func(ctx context.Context) (interface{}, error) {
+			return os.inventoryCircuit.Execute(func() (interface{}, error) {
+				resp, httpErr := os.inventoryClient.R().
+					SetContext(ctx).
+					SetHeader("Content-Type", "application/json").
+					SetBody(reserveRequest).
+					Post(os.inventoryServiceURL + "/inventory/reserve")
+
+				if httpErr != nil {
+					return nil, fmt.Errorf("HTTP error: %w", httpErr)
+				}
+
+				if resp.StatusCode() != http.StatusOK {
+					return nil, fmt.Errorf("inventory service returned status %d: %s", resp.StatusCode(), resp.String())
+				}
+
+				var response models.ReserveItemsResponse
+				if err := json.Unmarshal(resp.Body(), &response); err != nil {
+					return nil, fmt.Errorf("failed to parse response: %w", err)
+				}
+
+				if !response.Success {
+					return nil, fmt.Errorf("reservation failed: %s", response.Message)
+				}
+
+				return response, nil
+			})
 		})
 
-		return patterns.FormatError("Inventory", cbErr)
+		return patterns.FormatError("Inventory", err)
 	})
-
-	return err
 }
 
-// processPayment processes payment with circuit breaker and bulkhead patterns
-func (os *OrderService) processPayment(order *models.Order) error {
+// processPayment charges payment, retrying transient failures with backoff+jitter inside
+// the circuit breaker, itself inside the bulkhead; see reserveInventory for the composition
+// rationale.
+func (os *OrderService) processPayment(ctx context.Context, order *models.Order) error {
+	ctx, span := tracing.Tracer("order-service").Start(ctx, "charge_payment")
+	defer span.End()
+	span.SetAttributes(attribute.String("order_id", order.ID))
+
 	chargeRequest := models.ChargeRequest{
 		OrderID: order.ID,
 		Amount:  order.TotalAmount,
 	}
 
-	// Execute with bulkhead pattern
 	err := os.paymentBulkhead.Execute(func() error {
-		// Execute with circuit breaker pattern
-		_, cbErr := os.paymentCircuit.Execute(func() (interface{}, error) {
-			resp, httpErr := os.paymentClient.R().
-				SetHeader("Content-Type", "application/json").
-				SetBody(chargeRequest).
-				Post(os.paymentServiceURL + "/payment/charge")
-
-			if httpErr != nil {
-				return nil, fmt.Errorf("HTTP error: %w", httpErr)
-			}
-
-			if resp.StatusCode() != http.StatusOK {
-				return nil, fmt.Errorf("payment service returned status %d: %s", resp.StatusCode(), resp.String())
-			}
-
-			var response models.ChargeResponse
-			if err := json.Unmarshal(resp.Body(), &response); err != nil {
-				return nil, fmt.Errorf("failed to parse response: %w", err)
-			}
-
-			if response.Status != models.TransactionStatusCompleted {
-				return nil, fmt.Errorf("payment failed: %s", response.Message)
-			}
-
-			return response, nil
+		result, err := os.paymentRetrier.Execute(ctx, func(ctx context.Context) (interface{}, error) {
+			return os.paymentCircuit.Execute(func() (interface{}, error) {
+				// Idempotency-Key ties every retry of this order's charge to the same
+				// transaction, so a retry across a circuit-breaker trip can't double-charge.
+				resp, httpErr := os.paymentClient.R().
+					SetContext(ctx).
+					SetHeader("Content-Type", "application/json").
+					SetHeader("Idempotency-Key", order.ID).
+					SetBody(chargeRequest).
+					Post(os.paymentServiceURL + "/payment/charge")
+
+				if httpErr != nil {
+					return nil, fmt.Errorf("HTTP error: %w", httpErr)
+				}
+
+				if resp.StatusCode() != http.StatusOK {
+					return nil, fmt.Errorf("payment service returned status %d: %s", resp.StatusCode(), resp.String())
+				}
+
+				var response models.ChargeResponse
+				if err := json.Unmarshal(resp.Body(), &response); err != nil {
+					return nil, fmt.Errorf("failed to parse response: %w", err)
+				}
+
+				if response.Status != models.TransactionStatusCompleted {
+					return nil, fmt.Errorf("payment failed: %s", response.Message)
+				}
+
+				return response, nil
+			})
 		})
 
-		return patterns.FormatError("Payment", cbErr)
+		if err == nil {
+			order.TransactionID = result.(models.ChargeResponse).TransactionID
+		}
+
+		return patterns.FormatError("Payment", err)
 	})
 
+	span.SetAttributes(
+		attribute.String("transaction_id", order.TransactionID),
+		attribute.String("circuit_state", os.paymentCircuit.GetState()),
+	)
 	return err
 }
 
+// refundPayment reverses a successful charge, the sibling of releaseInventory for
+// compensating a payment that already succeeded when a later saga step fails. It is a
+// no-op if processPayment never reached a completed charge.
+func (os *OrderService) refundPayment(ctx context.Context, order *models.Order) error {
+	if order.TransactionID == "" {
+		return nil
+	}
+
+	refundRequest := models.RefundRequest{
+		TransactionID: order.TransactionID,
+		Reason:        "order saga compensation",
+	}
+
+	resp, err := os.paymentClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(refundRequest).
+		Post(os.paymentServiceURL + "/payment/refund")
+
+	if err != nil {
+		return fmt.Errorf("HTTP error: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("payment service returned status %d: %s", resp.StatusCode(), resp.String())
+	}
+
+	return nil
+}
+
 // releaseInventory releases reserved inventory (rollback operation)
-func (os *OrderService) releaseInventory(order *models.Order) error {
+func (os *OrderService) releaseInventory(ctx context.Context, order *models.Order) error {
 	releaseRequest := models.ReleaseItemsRequest{
 		OrderID: order.ID,
 		Items:   order.Items,
 	}
 
 	resp, err := os.inventoryClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(releaseRequest).
 		Post(os.inventoryServiceURL + "/inventory/release")
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/payment/charge", chargePayment)
+	router.GET("/payment/transaction/:id", getTransaction)
+	router.POST("/payment/refund", refundPayment)
+	return router
+}
+
+func doCharge(t *testing.T, router *gin.Engine, orderID string, idempotencyKey string, async bool) (int, map[string]interface{}) {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{"order_id": orderID, "amount": 42.50})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	url := "/payment/charge"
+	if async {
+		url += "?async=true"
+	}
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+// TestChargePaymentIdempotencyKeyReturnsOriginalTransaction pins the invariant a caller
+// retrying after e.g. a circuit breaker trip relies on: the same Idempotency-Key always
+// resolves to the transaction created on the first request, never a second charge.
+func TestChargePaymentIdempotencyKeyReturnsOriginalTransaction(t *testing.T) {
+	router := newTestRouter()
+	orderID := uuid.New().String()
+	key := uuid.New().String()
+
+	code1, resp1 := doCharge(t, router, orderID, key, false)
+	if code1 != http.StatusOK {
+		t.Fatalf("first charge: got status %d, want %d", code1, http.StatusOK)
+	}
+	firstTxID := resp1["transaction_id"]
+
+	code2, resp2 := doCharge(t, router, orderID, key, false)
+	if code2 != http.StatusOK {
+		t.Fatalf("duplicate charge: got status %d, want %d", code2, http.StatusOK)
+	}
+	if resp2["transaction_id"] != firstTxID {
+		t.Fatalf("duplicate charge: got transaction_id %v, want the original %v", resp2["transaction_id"], firstTxID)
+	}
+}
+
+// TestChargePaymentIdempotencyKeyDedupsConcurrentRequests pins the scenario the feature
+// exists for: a caller retrying after e.g. a circuit breaker trip can have two charges
+// carrying the same Idempotency-Key in flight at once. Both must resolve to a single
+// transaction, never two distinct charges.
+func TestChargePaymentIdempotencyKeyDedupsConcurrentRequests(t *testing.T) {
+	router := newTestRouter()
+	orderID := uuid.New().String()
+	key := uuid.New().String()
+
+	const concurrency = 20
+	txIDs := make([]string, concurrency)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			code, resp := doCharge(t, router, orderID, key, false)
+			if code != http.StatusOK {
+				t.Errorf("charge %d: got status %d, want %d", i, code, http.StatusOK)
+				return
+			}
+			txID, _ := resp["transaction_id"].(string)
+			txIDs[i] = txID
+		}(i)
+	}
+	wg.Wait()
+
+	first := txIDs[0]
+	if first == "" {
+		t.Fatal("first concurrent charge had no transaction_id")
+	}
+	for i, txID := range txIDs {
+		if txID != first {
+			t.Errorf("charge %d: got transaction_id %q, want the same transaction %q for every concurrent request with key %q", i, txID, first, key)
+		}
+	}
+
+	paymentService.mutex.RLock()
+	txCount := 0
+	for _, tx := range paymentService.transactions {
+		if tx.OrderID == orderID {
+			txCount++
+		}
+	}
+	paymentService.mutex.RUnlock()
+	if txCount != 1 {
+		t.Errorf("got %d transactions created for order %q, want exactly 1", txCount, orderID)
+	}
+}
+
+func TestChargePaymentWithoutIdempotencyKeyAlwaysCreatesNewTransaction(t *testing.T) {
+	router := newTestRouter()
+	orderID := uuid.New().String()
+
+	_, resp1 := doCharge(t, router, orderID, "", false)
+	_, resp2 := doCharge(t, router, orderID, "", false)
+
+	if resp1["transaction_id"] == resp2["transaction_id"] {
+		t.Fatal("got the same transaction_id for two charges with no Idempotency-Key, want two distinct transactions")
+	}
+}
+
+// TestChargePaymentAsyncCanBePolledToCompletion pins the status-polling contract: an async
+// charge comes back 202/processing immediately, and GET /payment/transaction/:id eventually
+// reports it completed once the background worker picks it up.
+func TestChargePaymentAsyncCanBePolledToCompletion(t *testing.T) {
+	router := newTestRouter()
+	orderID := uuid.New().String()
+
+	code, resp := doCharge(t, router, orderID, "", true)
+	if code != http.StatusAccepted {
+		t.Fatalf("async charge: got status %d, want %d", code, http.StatusAccepted)
+	}
+	txID, _ := resp["transaction_id"].(string)
+	if txID == "" {
+		t.Fatal("async charge response had no transaction_id")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payment/transaction/"+txID, nil))
+
+		var tx map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &tx); err != nil {
+			t.Fatalf("unmarshal transaction: %v", err)
+		}
+		if tx["status"] == "completed" {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("async charge never reached completed status within deadline")
+}
+
+func TestGetTransactionUnknownIDReturnsNotFound(t *testing.T) {
+	router := newTestRouter()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payment/transaction/does-not-exist", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -1,29 +1,44 @@
 package main
 
 import (
+	"context"
 	"math/rand"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/ashendes/resilience-demo/internal/chaos"
 	"github.com/ashendes/resilience-demo/internal/metrics"
 	"github.com/ashendes/resilience-demo/internal/models"
+	"github.com/ashendes/resilience-demo/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// chargeWorkers is the number of goroutines processing async charges off paymentService.chargeJobs.
+const chargeWorkers = 4
+
 // PaymentService manages payment operations
 type PaymentService struct {
-	transactions  map[string]*models.Transaction
-	mutex         sync.RWMutex
-	chaosEnabled  bool
-	chaosSlowMode bool
-	chaosMutex    sync.RWMutex
+	transactions map[string]*models.Transaction
+	idempotency  map[string]string // Idempotency-Key -> transaction ID
+	mutex        sync.RWMutex
+
+	chargeJobs chan chargeJob
+}
+
+// chargeJob is the work handed to a charge worker for async (?async=true) charges.
+type chargeJob struct {
+	transactionID string
+	req           models.ChargeRequest
+	requestID     string
 }
 
 var paymentService *PaymentService
+var paymentChaos *chaos.FaultInjector
 
 func init() {
 	// Initialize logger
@@ -35,17 +50,46 @@ func init() {
 
 	// Initialize payment service
 	paymentService = &PaymentService{
-		transactions:  make(map[string]*models.Transaction),
-		chaosEnabled:  false,
-		chaosSlowMode: false,
+		transactions: make(map[string]*models.Transaction),
+		idempotency:  make(map[string]string),
+		chargeJobs:   make(chan chargeJob, 256),
+	}
+
+	paymentChaos = chaos.NewFaultInjector("payment-service")
+
+	for i := 0; i < chargeWorkers; i++ {
+		go chargeWorker()
+	}
+}
+
+// chargeWorker drains chargeJobs, processing async charges in the background so
+// POST /payment/charge?async=true can return 202 Accepted immediately.
+func chargeWorker() {
+	for job := range paymentService.chargeJobs {
+		processCharge(job.transactionID, job.req, job.requestID)
 	}
 }
 
 func main() {
+	shutdownTracing, err := tracing.Init(context.Background(), "payment-service")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing: ", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.WithError(err).Warn("Failed to flush tracer provider on shutdown")
+		}
+	}()
+
 	router := gin.Default()
 
 	// Add Prometheus middleware
 	router.Use(metrics.PrometheusMiddleware("payment-service"))
+	router.Use(tracing.RequestID())
+	router.Use(tracing.Middleware("payment-service"))
+	// Fault injection applies uniformly to every endpoint instead of being sprinkled
+	// inside handlers.
+	router.Use(paymentChaos.Middleware())
 
 	// Health check endpoints
 	router.GET("/health", func(c *gin.Context) {
@@ -55,12 +99,15 @@ func main() {
 
 	// Payment endpoints
 	router.POST("/payment/charge", chargePayment)
+	router.GET("/payment/transaction/:id", getTransaction)
+	router.POST("/payment/refund", refundPayment)
 
 	// Chaos engineering endpoints
-	router.POST("/chaos/payment/enable", enableChaos)
-	router.POST("/chaos/payment/disable", disableChaos)
-	router.POST("/chaos/payment/slow", enableSlowMode)
-	router.POST("/chaos/payment/slow/disable", disableSlowMode)
+	router.GET("/chaos/config", getChaosConfig)
+	router.POST("/chaos/config", setChaosConfig)
+	router.GET("/chaos/scenario", getChaosScenario)
+	router.POST("/chaos/scenario", setChaosScenario)
+	router.DELETE("/chaos/config", clearChaosConfig)
 
 	// Metrics endpoint
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
@@ -73,15 +120,16 @@ func main() {
 
 func getStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"service":         "payment-service",
-		"status":          "healthy",
-		"chaos_enabled":   paymentService.getChaosEnabled(),
-		"chaos_slow_mode": paymentService.getSlowMode(),
-		"timestamp":       time.Now().Format(time.RFC3339),
+		"service":   "payment-service",
+		"status":    "healthy",
+		"timestamp": time.Now().Format(time.RFC3339),
 	})
 }
 
 func chargePayment(c *gin.Context) {
+	_, span := tracing.Tracer("payment-service").Start(c.Request.Context(), "chargePayment")
+	defer span.End()
+
 	var req models.ChargeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -91,34 +139,65 @@ func chargePayment(c *gin.Context) {
 		})
 		return
 	}
+	span.SetAttributes(attribute.String("order_id", req.OrderID))
+	requestID := tracing.RequestIDFrom(c)
 
-	// Simulate chaos
-	if err := simulateChaos(); err != nil {
-		log.WithFields(log.Fields{
-			"order_id": req.OrderID,
-			"amount":   req.Amount,
-		}).Warn("Chaos: Simulated payment failure")
-
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"transaction_id": "",
-			"status":         models.TransactionStatusFailed,
-			"message":        "Payment service temporarily unavailable: " + err.Error(),
-		})
-		return
+	async := c.Query("async") == "true"
+	transactionID := uuid.New().String()
+	span.SetAttributes(attribute.String("transaction_id", transactionID))
+	status := models.TransactionStatusCompleted
+	if async {
+		status = models.TransactionStatusProcessing
 	}
 
-	// Create transaction
-	transactionID := uuid.New().String()
 	transaction := &models.Transaction{
 		ID:        transactionID,
 		OrderID:   req.OrderID,
 		Amount:    req.Amount,
-		Status:    models.TransactionStatusCompleted,
+		Status:    status,
 		Timestamp: time.Now(),
 	}
 
+	// A caller retrying an in-flight or already-completed charge (e.g. after a circuit
+	// breaker trip) must not be charged twice: the same Idempotency-Key always resolves
+	// to the transaction created on the first request. chargeOrDedup does the lookup and,
+	// if absent, the insert under a single lock so two concurrent requests for the same
+	// key can't both miss the check and both create a transaction.
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if resp, duplicate := chargeOrDedup(idempotencyKey, transaction); duplicate {
+		span.SetAttributes(attribute.String("transaction_id", resp.TransactionID))
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	if async {
+		paymentService.chargeJobs <- chargeJob{transactionID: transactionID, req: req, requestID: requestID}
+		c.JSON(http.StatusAccepted, models.ChargeResponse{
+			TransactionID: transactionID,
+			Status:        models.TransactionStatusProcessing,
+			Message:       "Payment accepted, processing asynchronously",
+		})
+		return
+	}
+
+	processCharge(transactionID, req, requestID)
+
+	c.JSON(http.StatusOK, models.ChargeResponse{
+		TransactionID: transactionID,
+		Status:        models.TransactionStatusCompleted,
+		Message:       "Payment processed successfully",
+	})
+}
+
+// processCharge performs the actual charge and marks transactionID completed. Called
+// synchronously from chargePayment, or from a chargeWorker for async charges; requestID
+// carries the originating request's X-Request-ID across that async boundary for log
+// correlation.
+func processCharge(transactionID string, req models.ChargeRequest, requestID string) {
 	paymentService.mutex.Lock()
-	paymentService.transactions[transactionID] = transaction
+	if tx, exists := paymentService.transactions[transactionID]; exists {
+		tx.Status = models.TransactionStatusCompleted
+	}
 	paymentService.mutex.Unlock()
 
 	// Record payment amount metric
@@ -128,99 +207,152 @@ func chargePayment(c *gin.Context) {
 		"transaction_id": transactionID,
 		"order_id":       req.OrderID,
 		"amount":         req.Amount,
+		"request_id":     requestID,
 	}).Info("Payment processed successfully")
-
-	c.JSON(http.StatusOK, models.ChargeResponse{
-		TransactionID: transactionID,
-		Status:        models.TransactionStatusCompleted,
-		Message:       "Payment processed successfully",
-	})
 }
 
-func enableChaos(c *gin.Context) {
-	paymentService.setChaosEnabled(true)
-	metrics.ChaosFailureRate.WithLabelValues("payment-service").Set(1)
+// chargeOrDedup atomically resolves a charge under a single lock: if idempotencyKey
+// already maps to a transaction, that transaction's response is returned and transaction
+// is discarded; otherwise transaction is inserted and, if idempotencyKey is non-empty,
+// registered against it before the lock is released. Doing the check and the insert
+// under one critical section closes the race where two concurrent requests for the same
+// key both miss the check and both end up charged.
+func chargeOrDedup(idempotencyKey string, transaction *models.Transaction) (models.ChargeResponse, bool) {
+	paymentService.mutex.Lock()
+	defer paymentService.mutex.Unlock()
+
+	if idempotencyKey != "" {
+		if existingID, ok := paymentService.idempotency[idempotencyKey]; ok {
+			tx := paymentService.transactions[existingID]
+			return models.ChargeResponse{
+				TransactionID: tx.ID,
+				Status:        tx.Status,
+				Message:       "Duplicate request for this Idempotency-Key; returning original transaction",
+			}, true
+		}
+		paymentService.idempotency[idempotencyKey] = transaction.ID
+	}
 
-	log.Info("Chaos mode ENABLED for payment service")
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Chaos mode enabled",
-		"info":    "40% of requests will fail randomly",
-	})
+	paymentService.transactions[transaction.ID] = transaction
+	return models.ChargeResponse{}, false
 }
 
-func disableChaos(c *gin.Context) {
-	paymentService.setChaosEnabled(false)
-	paymentService.setSlowMode(false)
-	metrics.ChaosFailureRate.WithLabelValues("payment-service").Set(0)
-	metrics.ChaosSlowMode.WithLabelValues("payment-service").Set(0)
+// refundPayment reverses a completed charge, e.g. when an order saga compensates a
+// successful payment after a later step fails. It is idempotent on transaction ID: a
+// transaction already in TransactionStatusRefunded is returned as-is rather than refunded
+// twice.
+func refundPayment(c *gin.Context) {
+	var req models.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request: " + err.Error()})
+		return
+	}
 
-	log.Info("Chaos mode DISABLED for payment service")
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Chaos mode disabled",
-	})
-}
+	paymentService.mutex.Lock()
+	tx, exists := paymentService.transactions[req.TransactionID]
+	if !exists {
+		paymentService.mutex.Unlock()
+		c.JSON(http.StatusNotFound, models.RefundResponse{
+			TransactionID: req.TransactionID,
+			Status:        models.TransactionStatusFailed,
+			Message:       "Transaction not found",
+		})
+		return
+	}
 
-func enableSlowMode(c *gin.Context) {
-	paymentService.setSlowMode(true)
-	metrics.ChaosSlowMode.WithLabelValues("payment-service").Set(1)
+	if tx.Status == models.TransactionStatusRefunded {
+		paymentService.mutex.Unlock()
+		c.JSON(http.StatusOK, models.RefundResponse{
+			TransactionID: tx.ID,
+			Status:        models.TransactionStatusRefunded,
+			Message:       "Transaction already refunded",
+		})
+		return
+	}
 
-	log.Info("Slow mode ENABLED for payment service")
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Slow mode enabled",
-		"info":    "Requests will have 5-10 second delays",
-	})
-}
+	if tx.Status != models.TransactionStatusCompleted {
+		status := tx.Status
+		paymentService.mutex.Unlock()
+		c.JSON(http.StatusConflict, models.RefundResponse{
+			TransactionID: tx.ID,
+			Status:        status,
+			Message:       "Only a completed transaction can be refunded",
+		})
+		return
+	}
 
-func disableSlowMode(c *gin.Context) {
-	paymentService.setSlowMode(false)
-	metrics.ChaosSlowMode.WithLabelValues("payment-service").Set(0)
+	tx.Status = models.TransactionStatusRefunded
+	paymentService.mutex.Unlock()
 
-	log.Info("Slow mode DISABLED for payment service")
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Slow mode disabled",
+	log.WithFields(log.Fields{
+		"transaction_id": tx.ID,
+		"order_id":       tx.OrderID,
+		"reason":         req.Reason,
+		"request_id":     tracing.RequestIDFrom(c),
+	}).Info("Payment refunded")
+
+	c.JSON(http.StatusOK, models.RefundResponse{
+		TransactionID: tx.ID,
+		Status:        models.TransactionStatusRefunded,
+		Message:       "Payment refunded successfully",
 	})
 }
 
-// Helper methods
-func (ps *PaymentService) setChaosEnabled(enabled bool) {
-	ps.chaosMutex.Lock()
-	defer ps.chaosMutex.Unlock()
-	ps.chaosEnabled = enabled
+// getTransaction exposes transaction status for polling async charges.
+func getTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	paymentService.mutex.RLock()
+	tx, exists := paymentService.transactions[id]
+	paymentService.mutex.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Transaction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tx)
 }
 
-func (ps *PaymentService) getChaosEnabled() bool {
-	ps.chaosMutex.RLock()
-	defer ps.chaosMutex.RUnlock()
-	return ps.chaosEnabled
+func getChaosConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, paymentChaos.Config())
 }
 
-func (ps *PaymentService) setSlowMode(enabled bool) {
-	ps.chaosMutex.Lock()
-	defer ps.chaosMutex.Unlock()
-	ps.chaosSlowMode = enabled
+func setChaosConfig(c *gin.Context) {
+	var cfg chaos.Config
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid chaos config: " + err.Error()})
+		return
+	}
+
+	paymentChaos.SetConfig(cfg)
+	log.WithField("rules", len(cfg.Rules)).Info("Chaos config updated for payment service")
+	c.JSON(http.StatusOK, paymentChaos.Config())
 }
 
-func (ps *PaymentService) getSlowMode() bool {
-	ps.chaosMutex.RLock()
-	defer ps.chaosMutex.RUnlock()
-	return ps.chaosSlowMode
+func getChaosScenario(c *gin.Context) {
+	c.JSON(http.StatusOK, paymentChaos.Scenario())
 }
 
-func simulateChaos() error {
-	// Check if slow mode is enabled
-	if paymentService.getSlowMode() {
-		delay := time.Duration(5000+rand.Intn(5000)) * time.Millisecond
-		log.WithField("delay_ms", delay.Milliseconds()).Debug("Chaos: Simulating slow response")
-		time.Sleep(delay)
+func setChaosScenario(c *gin.Context) {
+	var scenario chaos.Scenario
+	if err := c.ShouldBindJSON(&scenario); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid chaos scenario: " + err.Error()})
+		return
 	}
 
-	// Check if failure mode is enabled
-	if paymentService.getChaosEnabled() {
-		// 40% failure rate
-		if rand.Float32() < 0.4 {
-			return gin.Error{Err: http.ErrAbortHandler, Type: gin.ErrorTypePublic}
-		}
-	}
+	paymentChaos.SetScenario(scenario)
+	log.WithFields(log.Fields{
+		"rules":            len(scenario.Rules),
+		"duration_seconds": scenario.DurationSeconds,
+		"seeded":           scenario.Seed != nil,
+		"request_id":       tracing.RequestIDFrom(c),
+	}).Info("Chaos scenario activated for payment service")
+	c.JSON(http.StatusOK, paymentChaos.Scenario())
+}
 
-	return nil
+func clearChaosConfig(c *gin.Context) {
+	paymentChaos.Clear()
+	log.Info("Chaos config cleared for payment service")
+	c.JSON(http.StatusOK, gin.H{"message": "Chaos config cleared"})
 }